@@ -0,0 +1,127 @@
+// keyctl JWT 서명 키 관리 도구
+//
+//	keyctl list
+//	keyctl generate [-alg ES256]
+//	keyctl promote -kid <kid>
+//
+// 키 회전 절차: generate 로 대기 키를 추가하고, JWKS 캐시(최대 5분)가 갱신된 뒤 promote 한다.
+// 은퇴한 키는 auth.key_retention 동안 검증에 계속 쓰인다.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/escape-ship/accountsrv/config"
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/pkg/postgres"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	configPath := flag.String("config", "config.yaml", "config file path")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: keyctl [-config path] list|generate|promote [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.New(*configPath)
+	if err != nil {
+		fatalf("load config: %v", err)
+	}
+	db, err := postgres.New(postgres.DBConnString(cfg.Database.DSN()))
+	if err != nil {
+		fatalf("connect database: %v", err)
+	}
+	defer db.Close()
+
+	store, err := auth.NewKeyStore(cfg.Auth, db.GetDB())
+	if err != nil {
+		fatalf("open key store: %v", err)
+	}
+
+	ctx := context.Background()
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	switch cmd {
+	case "list":
+		err = list(ctx, store)
+	case "generate":
+		fs := flag.NewFlagSet("generate", flag.ExitOnError)
+		alg := fs.String("alg", "ES256", "signing algorithm (RS256, ES256, ES384, ES512, EdDSA)")
+		fs.Parse(args)
+		err = generate(ctx, store, *alg)
+	case "promote":
+		fs := flag.NewFlagSet("promote", flag.ExitOnError)
+		kid := fs.String("kid", "", "key id to promote")
+		fs.Parse(args)
+		if *kid == "" {
+			fatalf("promote: -kid is required")
+		}
+		err = store.Promote(ctx, *kid)
+		if err == nil {
+			fmt.Printf("promoted %s\n", *kid)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fatalf("%s: %v", cmd, err)
+	}
+}
+
+func list(ctx context.Context, store auth.KeyStore) error {
+	keys, err := store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALG\tSTATE\tCREATED\tACTIVATED\tRETIRED")
+	for _, key := range keys {
+		state := "pending"
+		switch {
+		case key.IsRetired(now):
+			state = "retired"
+		case key.IsActive(now):
+			state = "active"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			key.ID, key.Method.Alg(), state,
+			formatTime(key.CreatedAt), formatTime(key.ActivatedAt), formatTime(key.RetiredAt))
+	}
+	return w.Flush()
+}
+
+func generate(ctx context.Context, store auth.KeyStore, alg string) error {
+	key, err := auth.GenerateSigningKey(alg)
+	if err != nil {
+		return err
+	}
+	if err := store.Add(ctx, key); err != nil {
+		return err
+	}
+	fmt.Printf("generated %s (%s), pending promotion\n", key.ID, key.Method.Alg())
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "keyctl: "+format+"\n", args...)
+	os.Exit(1)
+}
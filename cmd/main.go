@@ -1,14 +1,19 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log/slog"
 	"net"
 	"os"
+	"time"
 
 	"github.com/escape-ship/accountsrv/config"
 	"github.com/escape-ship/accountsrv/internal/app"
+	"github.com/escape-ship/accountsrv/internal/auth"
 	"github.com/escape-ship/accountsrv/internal/infra/redis"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	"github.com/escape-ship/accountsrv/internal/outbox"
 	"github.com/escape-ship/accountsrv/pkg/postgres"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/golang-migrate/migrate/v4/database/mysql"
@@ -50,27 +55,86 @@ func main() {
 	logger.Info("Redis connection established")
 
 	logger.Info("Connecting to database")
-	db, err := postgres.New(makeDSN(cfg.Database))
+	db, err := postgres.New(postgres.DBConnString(cfg.Database.DSN()))
 	if err != nil {
 		logger.Error("Failed to connect to database", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 	logger.Info("Database connection established")
 
+	logger.Info("Loading token signing keys", slog.String("key_store", cfg.Auth.KeyStore))
+	keyStore, err := auth.NewKeyStore(cfg.Auth, db.GetDB())
+	if err != nil {
+		logger.Error("Failed to open signing key store", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// 은퇴한 키로 서명된 리프레시 토큰이 만료될 때까지는 검증할 수 있어야 한다.
+	retention := max(cfg.Auth.KeyRetention, cfg.Auth.RefreshTokenTTL)
+	// 공유 비밀키 토큰은 발급 중이던 액세스 토큰이 만료될 때까지만 받는다.
+	legacyWindow := min(cfg.Auth.LegacySecretWindow, cfg.Auth.AccessTokenTTL)
+	keys, err := auth.NewKeyRing(context.Background(), keyStore,
+		auth.WithLegacySecret([]byte(cfg.Auth.JWTSecret), legacyWindow),
+		auth.WithRetention(retention))
+	if err != nil {
+		logger.Error("Failed to load token signing keys", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	logger.Info("Token signing keys loaded",
+		slog.String("kid", keys.SigningKey().ID),
+		slog.String("alg", keys.SigningKey().Method.Alg()))
+	if until := keys.LegacyUntil(); time.Now().Before(until) {
+		logger.Warn("Accepting legacy HS256 tokens until migration window ends", slog.Time("until", until))
+	}
+	if cfg.Auth.KeyReloadInterval > 0 {
+		go keys.Watch(context.Background(), cfg.Auth.KeyReloadInterval)
+	}
+
+	logger.Info("Initializing mailer", slog.String("driver", cfg.Mail.Driver))
+	mail, err := mailer.New(cfg.Mail, logger)
+	if err != nil {
+		logger.Error("Failed to initialize mailer", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	renderer, err := mailer.NewRenderer(cfg.Mail.BaseURL, cfg.Mail.DefaultLocale)
+	if err != nil {
+		logger.Error("Failed to load mail templates", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	outboxWorker := outbox.NewWorker(db.GetDB(), mail, renderer, cfg.Mail)
+
+	breached, err := auth.OpenBreachedCorpus(cfg.Password.Policy.BreachedFile)
+	if err != nil {
+		logger.Error("Failed to open breached password file", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer breached.Close()
+
+	// TOTP 비밀키, 제공자 토큰 암호화. 키가 없으면 2단계 인증 등록을 막는다.
+	var sealer *auth.Sealer
+	if cfg.Auth.KeyEncryptionKey != "" {
+		sealer, err = auth.NewSealer(cfg.Auth.KeyEncryptionKey)
+		if err != nil {
+			logger.Error("Failed to initialize sealer", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	providers, err := oauth.New(cfg.OAuth)
+	if err != nil {
+		logger.Error("Failed to initialize social login providers", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// 소셜 로그인은 제공자 토큰을 저장해야 하므로 암호화 키가 필요하다.
+	if len(providers) > 0 && sealer == nil {
+		logger.Error("Social login providers require key_encryption_key to store provider tokens")
+		os.Exit(1)
+	}
+	for name := range providers {
+		logger.Info("Social login provider enabled", slog.String("provider", name))
+	}
+
 	logger.Info("Initializing application")
-	application := app.New(db, lis, redisClient, cfg)
+	application := app.New(db, lis, redisClient, keys, breached, sealer, providers, outboxWorker, cfg)
 	logger.Info("Starting gRPC server")
 	application.Run()
 }
-
-// config.Database 값 사용
-func makeDSN(db config.Database) postgres.DBConnString {
-	return postgres.DBConnString(
-		fmt.Sprintf(
-			"postgres://%s:%s@%s:%d/%s?sslmode=%s&search_path=%s",
-			db.User, db.Password,
-			db.Host, db.Port,
-			db.DataBaseName, db.SSLMode, db.SchemaName,
-		),
-	)
-}
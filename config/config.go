@@ -1,16 +1,31 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
+	"net/netip"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type (
 	Config struct {
+		App      App      `mapstructure:"app"`
 		Database Database `mapstructure:"database"`
-		Auth     Auth     `mapstructure:"auth"` // 인증 관련 설정
+		Auth     Auth     `mapstructure:"auth"`     // 인증 관련 설정
+		Mail     Mail     `mapstructure:"mail"`     // 메일 발송 설정
+		Password Password `mapstructure:"password"` // 비밀번호 정책/해시 설정
+		OAuth    OAuth    `mapstructure:"oauth"`    // 소셜 로그인 제공자 설정
+	}
+
+	App struct {
+		LogLevel string `mapstructure:"log_level"`
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"` // HTTP (JWKS) 포트
+		// TrustedProxies x-forwarded-for 를 믿을 프록시(게이트웨이)의 IP 또는 CIDR. 비어 있으면 연결한 주소만 쓴다
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	}
 
 	Database struct {
@@ -24,7 +39,120 @@ type (
 	}
 
 	Auth struct {
-		JWTSecret string `mapstructure:"jwt_secret"`
+		JWTSecret          string        `mapstructure:"jwt_secret"`           // 비대칭 키 전환 전 HS256 토큰 검증용. 서명에는 쓰지 않는다
+		LegacySecretWindow time.Duration `mapstructure:"legacy_secret_window"` // 첫 비대칭 키 활성화 후 jwt_secret 토큰을 받는 기간, 최대 access_token_ttl. 기본 0 (받지 않음)
+		SigningKeyFile     string        `mapstructure:"signing_key_file"`     // RS256/ES256/EdDSA PEM 개인키
+
+		Issuer          string        `mapstructure:"issuer"`            // iss. 설정하면 검증 시에도 확인
+		Audience        []string      `mapstructure:"audience"`          // aud. 설정하면 검증 시 첫 번째 값 포함 여부 확인
+		AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`  // 기본 15m
+		RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"` // 기본 336h (14일), 세션 만료와 같다
+
+		PasswordResetTTL time.Duration `mapstructure:"password_reset_ttl"` // 비밀번호 재설정 토큰 유효시간, 기본 30m
+
+		EmailVerificationTTL       time.Duration `mapstructure:"email_verification_ttl"`       // 이메일 인증 토큰 유효시간, 기본 24h
+		VerificationResendInterval time.Duration `mapstructure:"verification_resend_interval"` // 인증 메일 재발송 최소 간격, 기본 1m
+		UnverifiedLogin            string        `mapstructure:"unverified_login"`             // 미인증 계정 로그인: "flag"(기본, 토큰에 표시만) 또는 "reject"
+
+		TOTPIssuer      string        `mapstructure:"totp_issuer"`       // 인증 앱에 표시되는 발급자, 기본 "Escape Ship"
+		MFAChallengeTTL time.Duration `mapstructure:"mfa_challenge_ttl"` // 로그인 2단계 인증 대기 시간, 기본 5m
+		MFAMaxAttempts  int           `mapstructure:"mfa_max_attempts"`  // 챌린지 하나로 시도할 수 있는 횟수, 기본 5
+
+		WebAuthn WebAuthn `mapstructure:"webauthn"`
+
+		OAuthStateTTL time.Duration `mapstructure:"oauth_state_ttl"` // 소셜 로그인 state/PKCE 유효시간, 기본 10m
+		SocialLinkTTL time.Duration `mapstructure:"social_link_ttl"` // 기존 계정 연결 확인 대기 시간, 기본 10m
+
+		RegisterGenericResponse bool `mapstructure:"register_generic_response"` // 이미 가입된 이메일도 가입 성공과 같은 응답을 주고 안내 메일을 보낸다
+
+		LoginThrottle LoginThrottle `mapstructure:"login_throttle"`
+
+		KeyStore          string        `mapstructure:"key_store"`           // "", "dir", "postgres"
+		KeyDir            string        `mapstructure:"key_dir"`             // key_store 가 dir 일 때 키 디렉터리
+		KeyEncryptionKey  string        `mapstructure:"key_encryption_key"`  // KEY_ENCRYPTION_KEY, base64 32바이트. 서명 개인키, TOTP 비밀키, 제공자 토큰 암호화. 소셜 로그인 사용 시 필수
+		KeyReloadInterval time.Duration `mapstructure:"key_reload_interval"` // 키 저장소 재조회 주기
+		KeyRetention      time.Duration `mapstructure:"key_retention"`       // 은퇴한 키 검증 유지 기간
+	}
+
+	// WebAuthn 패스키 Relying Party 설정
+	WebAuthn struct {
+		RPID          string        `mapstructure:"rp_id"`           // 도메인 (스킴/포트 제외), ex) "escape-ship.com"
+		RPDisplayName string        `mapstructure:"rp_display_name"` // 기본 "Escape Ship"
+		RPOrigins     []string      `mapstructure:"rp_origins"`      // 허용 origin, ex) "https://escape-ship.com"
+		ChallengeTTL  time.Duration `mapstructure:"challenge_ttl"`   // 등록/로그인 챌린지 유효시간, 기본 5m
+	}
+
+	// LoginThrottle 로그인 실패 제한. 이메일/사용자/IP 별로 센다.
+	LoginThrottle struct {
+		FreeAttempts       int           `mapstructure:"free_attempts"`        // 지연 없이 허용하는 실패 횟수, 기본 3
+		BaseDelay          time.Duration `mapstructure:"base_delay"`           // 첫 지연, 이후 실패마다 2배, 기본 1s
+		MaxDelay           time.Duration `mapstructure:"max_delay"`            // 지연 상한, 기본 5m
+		Window             time.Duration `mapstructure:"window"`               // 마지막 실패 후 카운터 유지 시간, 기본 15m
+		LockoutThreshold   int           `mapstructure:"lockout_threshold"`    // 이메일/사용자 잠금 실패 횟수, 기본 10
+		IPLockoutThreshold int           `mapstructure:"ip_lockout_threshold"` // IP 잠금 실패 횟수, 기본 100
+		LockoutDuration    time.Duration `mapstructure:"lockout_duration"`     // 잠금 시간, 기본 30m
+	}
+
+	// OAuth 소셜 로그인 제공자. client_id 를 설정한 제공자만 사용한다.
+	OAuth struct {
+		Kakao  OAuthProvider `mapstructure:"kakao"`
+		Naver  OAuthProvider `mapstructure:"naver"`
+		Google OAuthProvider `mapstructure:"google"`
+		Apple  OAuthProvider `mapstructure:"apple"`
+	}
+
+	// OAuthProvider 제공자 하나의 클라이언트 설정
+	OAuthProvider struct {
+		ClientID     string   `mapstructure:"client_id"`     // Apple 은 Services ID
+		ClientSecret string   `mapstructure:"client_secret"` // <PROVIDER>_CLIENT_SECRET. Apple 은 사용하지 않음
+		RedirectURI  string   `mapstructure:"redirect_uri"`  // 제공자 콘솔에 등록한 콜백 주소
+		Scopes       []string `mapstructure:"scopes"`        // 비우면 제공자 기본값
+
+		// Apple 은 client_secret 대신 아래 키로 서명한 JWT 를 쓴다.
+		TeamID         string `mapstructure:"team_id"`
+		KeyID          string `mapstructure:"key_id"`
+		PrivateKeyFile string `mapstructure:"private_key_file"` // .p8 (PKCS#8 EC) 개인키
+	}
+
+	Mail struct {
+		Driver        string        `mapstructure:"driver"`         // "smtp", "file", "log"(기본)
+		From          string        `mapstructure:"from"`           // 보내는 사람 주소
+		BaseURL       string        `mapstructure:"base_url"`       // 메일 본문 링크의 프론트엔드 주소
+		DefaultLocale string        `mapstructure:"default_locale"` // Accept-Language 가 없을 때 ("ko", "en")
+		Dir           string        `mapstructure:"dir"`            // driver 가 file 일 때 .eml 저장 디렉터리
+		SMTP          SMTP          `mapstructure:"smtp"`
+		PollInterval  time.Duration `mapstructure:"poll_interval"` // outbox 조회 주기
+		MaxAttempts   int           `mapstructure:"max_attempts"`  // 발송 재시도 횟수
+		Retention     time.Duration `mapstructure:"retention"`     // 발송했거나 포기한 메일을 지우기까지의 기간, 기본 168h. 0 이면 지우지 않는다
+	}
+
+	Password struct {
+		Policy PasswordPolicy `mapstructure:"policy"`
+		Hash   PasswordHash   `mapstructure:"hash"`
+	}
+
+	// PasswordPolicy 가입/비밀번호 변경/재설정에 적용하는 비밀번호 정책
+	PasswordPolicy struct {
+		MinLength    int      `mapstructure:"min_length"`    // 최소 글자 수, 기본 8
+		MaxLength    int      `mapstructure:"max_length"`    // 최대 바이트 수, 기본 72 (bcrypt 상한)
+		BannedWords  []string `mapstructure:"banned_words"`  // 포함하면 안 되는 단어. 이메일 @ 앞부분은 항상 포함
+		BreachedFile string   `mapstructure:"breached_file"` // 정렬된 유출 비밀번호 SHA-1 목록 파일. 비우면 검사하지 않음
+	}
+
+	// PasswordHash argon2id 파라미터. 올리면 다음 로그인 때 기존 해시가 새 값으로 다시 저장된다.
+	PasswordHash struct {
+		Memory      uint32 `mapstructure:"memory"`      // KiB, 기본 65536 (64MiB)
+		Iterations  uint32 `mapstructure:"iterations"`  // 기본 3
+		Parallelism uint8  `mapstructure:"parallelism"` // 기본 2
+		SaltLength  uint32 `mapstructure:"salt_length"` // 바이트, 기본 16
+		KeyLength   uint32 `mapstructure:"key_length"`  // 바이트, 기본 32
+	}
+
+	SMTP struct {
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"`
+		Username string `mapstructure:"username"`
+		Password string `mapstructure:"password"` // SMTP_PASSWORD
 	}
 )
 
@@ -32,6 +160,34 @@ func New(path string) (*Config, error) {
 	vp := viper.New()
 	vp.SetConfigFile(path)
 	vp.AutomaticEnv()
+	vp.SetDefault("auth.access_token_ttl", 15*time.Minute)
+	vp.SetDefault("auth.refresh_token_ttl", 14*24*time.Hour)
+	vp.SetDefault("auth.password_reset_ttl", 30*time.Minute)
+	vp.SetDefault("auth.email_verification_ttl", 24*time.Hour)
+	vp.SetDefault("auth.verification_resend_interval", time.Minute)
+	vp.SetDefault("auth.unverified_login", "flag")
+	vp.SetDefault("auth.totp_issuer", "Escape Ship")
+	vp.SetDefault("auth.mfa_challenge_ttl", 5*time.Minute)
+	vp.SetDefault("auth.mfa_max_attempts", 5)
+	vp.SetDefault("auth.webauthn.rp_display_name", "Escape Ship")
+	vp.SetDefault("auth.webauthn.challenge_ttl", 5*time.Minute)
+	vp.SetDefault("auth.oauth_state_ttl", 10*time.Minute)
+	vp.SetDefault("auth.social_link_ttl", 10*time.Minute)
+	vp.SetDefault("auth.login_throttle.free_attempts", 3)
+	vp.SetDefault("auth.login_throttle.base_delay", time.Second)
+	vp.SetDefault("auth.login_throttle.max_delay", 5*time.Minute)
+	vp.SetDefault("auth.login_throttle.window", 15*time.Minute)
+	vp.SetDefault("auth.login_throttle.lockout_threshold", 10)
+	vp.SetDefault("auth.login_throttle.ip_lockout_threshold", 100)
+	vp.SetDefault("auth.login_throttle.lockout_duration", 30*time.Minute)
+	vp.SetDefault("password.policy.min_length", 8)
+	vp.SetDefault("password.policy.max_length", 72)
+	vp.SetDefault("mail.driver", "log")
+	vp.SetDefault("mail.default_locale", "ko")
+	vp.SetDefault("mail.poll_interval", 5*time.Second)
+	vp.SetDefault("mail.max_attempts", 10)
+	vp.SetDefault("mail.retention", 168*time.Hour)
+	vp.SetDefault("mail.smtp.port", 587)
 
 	dir, err := os.Getwd()
 	if err != nil {
@@ -51,5 +207,61 @@ func New(path string) (*Config, error) {
 	if jwtSecret := os.Getenv("JWT_SECRET"); jwtSecret != "" {
 		cfg.Auth.JWTSecret = jwtSecret
 	}
+	if signingKeyFile := os.Getenv("JWT_SIGNING_KEY_FILE"); signingKeyFile != "" {
+		cfg.Auth.SigningKeyFile = signingKeyFile
+	}
+	if keyEncryptionKey := os.Getenv("KEY_ENCRYPTION_KEY"); keyEncryptionKey != "" {
+		cfg.Auth.KeyEncryptionKey = keyEncryptionKey
+	}
+	if smtpPassword := os.Getenv("SMTP_PASSWORD"); smtpPassword != "" {
+		cfg.Mail.SMTP.Password = smtpPassword
+	}
+	// 기존 배포에서 쓰던 카카오 환경변수
+	if clientID := os.Getenv("KAKAO_CLIENT_ID"); clientID != "" {
+		cfg.OAuth.Kakao.ClientID = clientID
+	}
+	if redirectURI := os.Getenv("KAKAO_REDIRECT_URI"); redirectURI != "" {
+		cfg.OAuth.Kakao.RedirectURI = redirectURI
+	}
+	for env, provider := range map[string]*OAuthProvider{
+		"KAKAO_CLIENT_SECRET":  &cfg.OAuth.Kakao,
+		"NAVER_CLIENT_SECRET":  &cfg.OAuth.Naver,
+		"GOOGLE_CLIENT_SECRET": &cfg.OAuth.Google,
+	} {
+		if secret := os.Getenv(env); secret != "" {
+			provider.ClientSecret = secret
+		}
+	}
+	if _, err := cfg.App.TrustedProxyPrefixes(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
+
+// TrustedProxyPrefixes app.trusted_proxies 를 파싱한다. 단일 IP 는 /32 (IPv6 /128) 로 본다.
+func (app App) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(app.TrustedProxies))
+	for _, entry := range app.TrustedProxies {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("app.trusted_proxies: invalid address %q", entry)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// DSN postgres 접속 문자열
+func (db Database) DSN() string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s?sslmode=%s&search_path=%s",
+		db.User, db.Password,
+		db.Host, db.Port,
+		db.DataBaseName, db.SSLMode, db.SchemaName,
+	)
+}
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- 리프레시 토큰 회전(rotation) 및 재사용 탐지를 위한 컬럼
+ALTER TABLE account.refresh_tokens
+    ALTER COLUMN token TYPE TEXT,
+    ADD COLUMN family_id UUID,             -- 같은 로그인에서 회전된 토큰 묶음
+    ADD COLUMN consumed_at TIMESTAMP,      -- 새 토큰으로 교환된 시각
+    ADD COLUMN revoked_at TIMESTAMP;       -- 폐기된 시각
+
+UPDATE account.refresh_tokens SET family_id = id WHERE family_id IS NULL;
+
+ALTER TABLE account.refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX idx_refresh_tokens_family_id ON account.refresh_tokens (family_id);
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE account.sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    device_name TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,         -- 리프레시 토큰 회전 시 연장
+    revoked_at TIMESTAMP,
+    CONSTRAINT fk_sessions_user_id FOREIGN KEY (user_id) REFERENCES account.users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sessions_user_id ON account.sessions (user_id);
+
+-- 리프레시 토큰이 속한 세션 (기존 토큰은 NULL)
+ALTER TABLE account.refresh_tokens
+    ADD COLUMN session_id UUID,
+    ADD CONSTRAINT fk_session_id FOREIGN KEY (session_id) REFERENCES account.sessions(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_refresh_tokens_session_id ON account.refresh_tokens (session_id);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- JWT 서명 키 링. activated_at 이 없으면 대기, retired_at 이 있으면 검증 전용
+CREATE TABLE account.signing_keys (
+    kid TEXT PRIMARY KEY,                  -- RFC 7638 JWK thumbprint
+    algorithm TEXT NOT NULL,               -- RS256, ES256, EdDSA ...
+    private_key BYTEA NOT NULL,            -- AES-256-GCM 으로 암호화된 PKCS#8 PEM
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMP,
+    retired_at TIMESTAMP
+);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- 리프레시 토큰 원문 대신 SHA-256 해시(hex)만 저장한다.
+ALTER TABLE account.refresh_tokens ADD COLUMN token_hash TEXT;
+
+UPDATE account.refresh_tokens SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+
+ALTER TABLE account.refresh_tokens
+    ALTER COLUMN token_hash SET NOT NULL,
+    ADD CONSTRAINT refresh_tokens_token_hash_key UNIQUE (token_hash),
+    DROP COLUMN token;
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE account.roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO account.roles (name, description) VALUES
+    ('customer', '일반 고객'),
+    ('admin', '백오피스 관리자');
+
+CREATE TABLE account.user_roles (
+    user_id UUID NOT NULL REFERENCES account.users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL REFERENCES account.roles(name) ON DELETE CASCADE,
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role)
+);
+
+-- 기존 사용자는 모두 customer. 첫 admin 은 DB 에서 직접 부여한다.
+--   INSERT INTO account.user_roles (user_id, role) VALUES ('<user id>', 'admin');
+INSERT INTO account.user_roles (user_id, role)
+SELECT id, 'customer' FROM account.users;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- 비밀번호 재설정 토큰. 원문은 메일로만 전달하고 SHA-256 해시만 저장한다.
+CREATE TABLE account.password_reset_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES account.users(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,                     -- 사용(또는 무효화)된 시각
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON account.password_reset_tokens (user_id);
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE account.users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- 이메일 인증 토큰. 원문은 메일로만 전달하고 SHA-256 해시만 저장한다.
+CREATE TABLE account.email_verification_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES account.users(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,                     -- 사용(또는 무효화)된 시각
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_email_verification_tokens_user_id ON account.email_verification_tokens (user_id);
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+-- 트랜잭션 아웃박스. 메일을 발생시킨 변경과 같은 트랜잭션에서 쌓고 워커가 발송한다.
+CREATE TABLE account.outbox (
+    id UUID PRIMARY KEY,
+    kind TEXT NOT NULL,                    -- 메일 템플릿 종류 (password_reset 등)
+    recipient TEXT NOT NULL,
+    locale TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',   -- 템플릿 데이터
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_pending ON account.outbox (next_attempt_at) WHERE sent_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- TOTP 2단계 인증. secret 은 Sealer 로 암호화하며 user_id 를 associated data 로 묶는다.
+CREATE TABLE account.user_totp (
+    user_id UUID PRIMARY KEY REFERENCES account.users(id) ON DELETE CASCADE,
+    secret BYTEA NOT NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,   -- 마지막으로 사용한 30초 구간. 같은 코드 재사용 방지
+    confirmed_at TIMESTAMP,                     -- NULL 이면 등록만 하고 확인 전
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 복구 코드. 원문은 확인 시 한 번만 보여주고 SHA-256 해시만 저장한다.
+CREATE TABLE account.recovery_codes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES account.users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- 소셜 로그인 제공자 계정과 사용자의 연결. 이메일이 아닌 제공자 사용자 ID 로 찾는다.
+CREATE TABLE account.user_identities (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES account.users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,                     -- kakao, naver, google, apple
+    provider_user_id TEXT NOT NULL,             -- 제공자 안에서 바뀌지 않는 사용자 ID (카카오 회원번호, OIDC sub)
+    email TEXT NOT NULL DEFAULT '',             -- 마지막 로그인 때 제공자가 알려준 이메일 (표시용)
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP,
+    UNIQUE (provider, provider_user_id),
+    UNIQUE (user_id, provider)
+);
+
+COMMIT;
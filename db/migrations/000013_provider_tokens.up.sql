@@ -0,0 +1,24 @@
+BEGIN;
+
+-- 소셜 로그인 제공자 토큰. 다른 서비스가 제공자 API(카카오톡 메시지 등)를 호출할 때 쓴다.
+-- 토큰은 key_encryption_key 로 암호화한다 (AD = identity_id).
+CREATE TABLE account.provider_tokens (
+    identity_id UUID PRIMARY KEY REFERENCES account.user_identities(id) ON DELETE CASCADE,
+    access_token BYTEA NOT NULL,
+    refresh_token BYTEA,
+    expires_at TIMESTAMP,                       -- 액세스 토큰 만료
+    refresh_expires_at TIMESTAMP,               -- 리프레시 토큰 만료
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 제공자 토큰을 조회하는 내부 서비스
+INSERT INTO account.roles (name, description) VALUES
+    ('service', '내부 서비스');
+
+-- 세션이 없는 리프레시 토큰은 카카오 콜백이 저장하던 카카오 리프레시 토큰(해시)과
+-- 세션 도입(000003) 전에 발급된 리프레시 토큰이다. 해시만으로는 둘을 구분할 수 없어 모두 지운다.
+-- 세션 도입 전에 로그인한 사용자는 이 마이그레이션 후 다시 로그인해야 하며,
+-- RefreshToken 은 더 이상 세션 없는 토큰을 새 세션으로 옮기지 않는다.
+DELETE FROM account.refresh_tokens WHERE session_id IS NULL;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- 이메일이 없는 소셜 로그인 사용자는 (provider, provider_user_id) 로만 찾으므로 빈 이메일을 여러 명이 쓸 수 있게 한다.
+ALTER TABLE account.users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX users_email_key ON account.users (email) WHERE email <> '';
+
+-- user_identities 도입(000012) 전 카카오 로그인으로 가입한 사용자 (비밀번호 없음).
+-- 당시에는 카카오 회원번호를 저장하지 않았으므로 이메일로 자리표시 연결을 만들고,
+-- 같은 이메일을 인증된 이메일로 알려주는 카카오 계정이 처음 로그인할 때 실제 회원번호로 바꾼다.
+-- 빈 이메일 사용자는 여러 카카오 계정이 함께 쓰던 계정이라 누구의 것인지 알 수 없어 연결하지 않는다.
+INSERT INTO account.user_identities (id, user_id, provider, provider_user_id, email)
+SELECT gen_random_uuid(), u.id, 'kakao', 'legacy-email:' || lower(u.email), u.email
+FROM account.users u
+WHERE u.password_hash = ''
+  AND u.email <> ''
+  AND NOT EXISTS (SELECT 1 FROM account.user_identities i WHERE i.user_id = u.id);
+
+COMMIT;
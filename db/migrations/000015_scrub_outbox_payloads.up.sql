@@ -0,0 +1,7 @@
+BEGIN;
+
+-- 발송된 메일의 템플릿 데이터에는 재설정/인증 토큰 원문이 들어 있으므로 남기지 않는다.
+-- 이후로는 MarkOutboxMessageSent 가 발송과 함께 비우고, Worker 가 보존 기간이 지난 행을 지운다.
+UPDATE account.outbox SET payload = '{}' WHERE sent_at IS NOT NULL;
+
+COMMIT;
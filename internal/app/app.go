@@ -1,14 +1,21 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net"
+	"net/http"
 
 	"github.com/escape-ship/accountsrv/config"
+	"github.com/escape-ship/accountsrv/internal/auth"
 	"github.com/escape-ship/accountsrv/internal/infra/redis"
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	"github.com/escape-ship/accountsrv/internal/outbox"
 	"github.com/escape-ship/accountsrv/internal/service"
 	"github.com/escape-ship/accountsrv/pkg/postgres"
-	pb "github.com/escape-ship/protos/gen"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
@@ -17,24 +24,45 @@ type App struct {
 	pg             postgres.DBEngine
 	AccountService *service.AccountService
 	Listener       net.Listener
+	httpServer     *http.Server
+	outboxWorker   *outbox.Worker
 }
 
-func New(pg postgres.DBEngine, listener net.Listener, redisClient *redis.RedisClient, cfg *config.Config) *App {
+func New(pg postgres.DBEngine, listener net.Listener, redisClient *redis.RedisClient, keys *auth.KeyRing, breached *auth.BreachedCorpus, sealer *auth.Sealer, providers map[string]oauth.Provider, outboxWorker *outbox.Worker, cfg *config.Config) *App {
 	return &App{
 		pg:             pg,
 		Listener:       listener,
-		AccountService: service.NewAccountService(pg, redisClient, cfg),
+		AccountService: service.NewAccountService(pg, redisClient, keys, breached, sealer, providers, cfg),
+		httpServer: &http.Server{
+			Addr:    fmt.Sprintf("%s:%d", cfg.App.Host, cfg.App.Port),
+			Handler: newHTTPHandler(keys),
+		},
+		outboxWorker: outboxWorker,
 	}
 }
 
 // App 실행: gRPC 서버와 Kafka consumer를 모두 실행
 func (a *App) Run() {
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(a.AccountService.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(a.AccountService.StreamInterceptor()),
+	)
 	// gRPC 서비스 등록
 	pb.RegisterAccountServiceServer(grpcServer, a.AccountService)
 
 	reflection.Register(grpcServer)
 
+	// 아웃박스 메일 발송
+	go a.outboxWorker.Run(context.Background())
+
+	// JWKS 등 HTTP 엔드포인트
+	go func() {
+		log.Printf("HTTP server listening on %s", a.httpServer.Addr)
+		if err := a.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("failed to serve HTTP: %v", err)
+		}
+	}()
+
 	log.Println("gRPC server listening on :8082")
 	if err := grpcServer.Serve(a.Listener); err != nil {
 		log.Fatalf("failed to serve: %v", err)
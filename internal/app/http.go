@@ -0,0 +1,19 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+)
+
+// newHTTPHandler gRPC 외에 HTTP 로 직접 제공하는 엔드포인트
+func newHTTPHandler(keys *auth.KeyRing) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(w).Encode(keys.JWKS())
+	})
+	return mux
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxBreachedLineLength 한 줄 최대 길이 (SHA-1 40자 + ":" + 횟수)
+const maxBreachedLineLength = 64
+
+// BreachedCorpus 유출 비밀번호 SHA-1 목록 파일을 조회한다.
+// 파일은 한 줄에 하나씩 SHA-1 hex 로 정렬되어 있어야 하며, Pwned Passwords 의
+// "HASH:COUNT" (ordered by hash) 형식을 그대로 쓸 수 있다. 파일 전체를 메모리에 올리지 않고
+// 이진 탐색하므로 외부 API 없이 오프라인으로 동작한다.
+type BreachedCorpus struct {
+	file *os.File
+	size int64
+}
+
+// OpenBreachedCorpus path 의 목록 파일을 연다. path 가 비어 있으면 nil 을 반환하고, nil 코퍼스는 항상 false 를 반환한다.
+func OpenBreachedCorpus(path string) (*BreachedCorpus, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stat breached password file: %w", err)
+	}
+	return &BreachedCorpus{file: f, size: info.Size()}, nil
+}
+
+// Contains 비밀번호가 유출 목록에 있는지
+func (c *BreachedCorpus) Contains(password string) (bool, error) {
+	if c == nil {
+		return false, nil
+	}
+	sum := sha1.Sum([]byte(password))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	// lo 이전에 시작하는 줄은 모두 target 보다 작고, hi 이후에 시작하는 줄은 모두 크다.
+	lo, hi := int64(0), c.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		begin, line, err := c.lineAt(mid)
+		if err != nil {
+			return false, err
+		}
+		if begin < 0 || begin >= hi {
+			hi = mid
+			continue
+		}
+		switch key := breachedKey(line); {
+		case key == target:
+			return true, nil
+		case key < target:
+			lo = begin + 1
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// Close 목록 파일을 닫는다.
+func (c *BreachedCorpus) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+// lineAt offset 이후(offset 포함)에서 시작하는 첫 줄과 그 시작 위치. 줄이 없으면 begin 은 -1 이다.
+func (c *BreachedCorpus) lineAt(offset int64) (int64, []byte, error) {
+	begin := offset
+	if offset > 0 {
+		// 직전 바이트부터 읽어 offset 이 정확히 줄의 시작인 경우도 처리한다.
+		begin = offset - 1
+	}
+	buf := make([]byte, 2*maxBreachedLineLength)
+	n, err := c.file.ReadAt(buf, begin)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	buf = buf[:n]
+	if offset > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return -1, nil, nil
+		}
+		buf = buf[i+1:]
+		begin += int64(i + 1)
+	}
+	if len(buf) == 0 {
+		return -1, nil, nil
+	}
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i]
+	}
+	return begin, buf, nil
+}
+
+// breachedKey "HASH:COUNT" 줄에서 대문자 해시 부분
+func breachedKey(line []byte) string {
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.ToUpper(string(bytes.TrimSpace(line)))
+}
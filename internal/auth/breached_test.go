@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeCorpus content 를 그대로 목록 파일로 쓰고 연다.
+func writeCorpus(t *testing.T, content string) *BreachedCorpus {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenBreachedCorpus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestBreachedCorpusContains(t *testing.T) {
+	// 카운트 자릿수를 달리해 줄 길이가 제각각인 파일
+	var lines []string
+	for i := 0; i < 500; i++ {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(fmt.Sprintf("password%d", i)), i*37%100000))
+	}
+	sort.Strings(lines)
+	c := writeCorpus(t, strings.Join(lines, "\n")+"\n")
+
+	for i := 0; i < 500; i++ {
+		password := fmt.Sprintf("password%d", i)
+		if ok, err := c.Contains(password); err != nil || !ok {
+			t.Fatalf("Contains(%q) = %v, %v, want true", password, ok, err)
+		}
+	}
+	for i := 0; i < 500; i++ {
+		password := fmt.Sprintf("not-breached%d", i)
+		if ok, err := c.Contains(password); err != nil || ok {
+			t.Fatalf("Contains(%q) = %v, %v, want false", password, ok, err)
+		}
+	}
+}
+
+func TestBreachedCorpusBoundaries(t *testing.T) {
+	target := sha1Hex("hunter2")
+	lowest := strings.Repeat("0", 40)
+	highest := strings.Repeat("F", 40)
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"empty file", "", false},
+		{"only line", target + "\n", true},
+		{"only line without newline", target, true},
+		{"first line", target + ":1\n" + highest + ":1\n", true},
+		{"last line", lowest + ":1\n" + target + ":1\n", true},
+		{"last line without newline", lowest + ":1\n" + target + ":1", true},
+		{"middle line", lowest + ":3\n" + target + ":2\n" + highest + ":1\n", true},
+		{"lowercase hash", strings.ToLower(target) + ":5\n", true},
+		{"crlf line endings", lowest + ":1\r\n" + target + ":1\r\n" + highest + ":1\r\n", true},
+		{"below every line", highest + ":1\n", false},
+		{"above every line", lowest + ":1\n", false},
+		{"between lines", lowest + ":1\n" + highest + ":1\n", false},
+		{"prefix only", target[:39] + "\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := writeCorpus(t, tt.content)
+			ok, err := c.Contains("hunter2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("Contains = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreachedCorpusNil(t *testing.T) {
+	c, err := OpenBreachedCorpus("")
+	if err != nil || c != nil {
+		t.Fatalf("OpenBreachedCorpus(\"\") = %v, %v, want nil", c, err)
+	}
+	if ok, err := c.Contains("hunter2"); ok || err != nil {
+		t.Errorf("nil corpus Contains = %v, %v, want false", ok, err)
+	}
+}
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// HashToken 토큰을 DB 에 저장/조회할 때 쓰는 SHA-256 해시(hex).
+// 리프레시 토큰처럼 충분히 무작위한 값에만 사용한다. 비밀번호에는 쓰지 않는다.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewOpaqueToken 256비트 난수 토큰(base64url). 메일 링크 등으로 전달하는 일회용 토큰에 쓴다.
+func NewOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestHashToken(t *testing.T) {
+	// FIPS 180-2 의 SHA-256 "abc" 테스트 벡터
+	if got, want := HashToken("abc"), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; got != want {
+		t.Errorf("HashToken(abc) = %s, want %s", got, want)
+	}
+	if HashToken("token-a") == HashToken("token-b") {
+		t.Error("different tokens have the same hash")
+	}
+}
+
+func TestNewOpaqueToken(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		token, err := NewOpaqueToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(raw) != 32 {
+			t.Fatalf("token %q is not 32 bytes of base64url: %v", token, err)
+		}
+		if seen[token] {
+			t.Fatalf("duplicate token %q", token)
+		}
+		seen[token] = true
+	}
+}
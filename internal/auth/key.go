@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// hmacKeyID 공유 비밀키(HS256) 사용 시 kid. JWKS 로는 공개되지 않는다.
+const hmacKeyID = "hs256"
+
+// SigningKey JWT 서명/검증 키
+type SigningKey struct {
+	ID     string // kid
+	Method jwt.SigningMethod
+	// Private 서명 키. 비대칭 키는 crypto.Signer, HMAC 은 []byte
+	Private interface{}
+	// Public 검증 키. HMAC 은 Private 과 같은 []byte
+	Public interface{}
+
+	CreatedAt   time.Time
+	ActivatedAt time.Time // 서명 키로 승격된 시각. zero 면 대기 중
+	RetiredAt   time.Time // 서명에서 물러난 시각. 이후에는 검증에만 쓰인다
+}
+
+// LoadSigningKeyFile PEM 파일에서 서명 키를 읽는다.
+func LoadSigningKeyFile(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read signing key %s: %w", path, err)
+	}
+	return ParseSigningKeyPEM(data)
+}
+
+// ParseSigningKeyPEM PKCS#8, PKCS#1(RSA), SEC1(EC) 형식의 PEM 개인키를 파싱한다.
+// 키 종류에 따라 RS256, ES256/ES384/ES512, EdDSA 중 하나로 서명한다.
+func ParseSigningKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var private interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	return NewSigningKey(private)
+}
+
+// NewSigningKey 비대칭 개인키로 SigningKey 를 만든다. kid 는 RFC 7638 JWK thumbprint 이다.
+func NewSigningKey(private interface{}) (*SigningKey, error) {
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+
+	var method jwt.SigningMethod
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key must be at least 2048 bits, got %d", k.N.BitLen())
+		}
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			method = jwt.SigningMethodES256
+		case elliptic.P384():
+			method = jwt.SigningMethodES384
+		case elliptic.P521():
+			method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %s", k.Curve.Params().Name)
+		}
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+
+	key := &SigningKey{
+		Method:  method,
+		Private: private,
+		Public:  signer.Public(),
+	}
+	jwk, err := key.JWK()
+	if err != nil {
+		return nil, err
+	}
+	key.ID, err = jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// NewHMACKey 공유 비밀키(HS256) 키를 만든다. 비대칭 키 전환 전 토큰의 검증에만 쓴다.
+func NewHMACKey(secret []byte) *SigningKey {
+	return &SigningKey{
+		ID:      hmacKeyID,
+		Method:  jwt.SigningMethodHS256,
+		Private: secret,
+		Public:  secret,
+	}
+}
+
+// IsSymmetric 공유 비밀키 여부
+func (k *SigningKey) IsSymmetric() bool {
+	_, ok := k.Public.([]byte)
+	return ok
+}
+
+// IsActive now 기준으로 서명에 쓸 수 있는 키인지 여부
+func (k *SigningKey) IsActive(now time.Time) bool {
+	return !k.ActivatedAt.IsZero() && !k.ActivatedAt.After(now) && !k.IsRetired(now)
+}
+
+// IsRetired now 기준으로 은퇴한 키인지 여부
+func (k *SigningKey) IsRetired(now time.Time) bool {
+	return !k.RetiredAt.IsZero() && !k.RetiredAt.After(now)
+}
+
+// MarshalPrivateKeyPEM 개인키를 PKCS#8 PEM 으로 직렬화한다.
+func (k *SigningKey) MarshalPrivateKeyPEM() ([]byte, error) {
+	if k.IsSymmetric() {
+		return nil, errors.New("cannot marshal symmetric key")
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// GenerateSigningKey alg(RS256, ES256, ES384, ES512, EdDSA)에 맞는 새 서명 키를 만든다.
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	var private interface{}
+	var err error
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	case jwt.SigningMethodES256.Alg():
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwt.SigningMethodES384.Alg():
+		private, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case jwt.SigningMethodES512.Alg():
+		private, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key, err := NewSigningKey(private)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedAt = time.Now()
+	return key, nil
+}
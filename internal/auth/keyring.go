@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultRetention 은퇴한 키를 검증용으로 남겨 두는 기간. 리프레시 토큰 수명과 같다.
+const defaultRetention = 14 * 24 * time.Hour
+
+// KeyRing 저장소에서 읽은 서명 키 묶음.
+// 활성 키 하나로 서명하고, 대기 중인 키와 은퇴 후 보존 기간이 남은 키는 검증과 JWKS 공개에 쓴다.
+// 검증 시에는 토큰 헤더의 kid 로 키를 고른다.
+type KeyRing struct {
+	store        KeyStore
+	legacy       *SigningKey
+	legacyWindow time.Duration
+	retention    time.Duration
+
+	mu      sync.RWMutex
+	signing *SigningKey
+	keys    map[string]*SigningKey
+	// legacyUntil 공유 비밀키로 서명된 토큰을 검증하는 마지막 시각
+	legacyUntil time.Time
+}
+
+// Option KeyRing 설정
+type Option func(*KeyRing)
+
+// WithLegacySecret 비대칭 키 전환 전에 발급된 HS256 토큰을 검증할 공유 비밀키.
+// 이 키로는 서명하지 않으며, 첫 비대칭 키가 활성화된 뒤 window 동안만 검증한다.
+// 공유 비밀키를 가진 다른 서비스도 토큰을 만들 수 있으므로 window 는 액세스 토큰 수명을 넘기지 않아야 한다.
+// window 가 0 이하이면 공유 비밀키 토큰을 받지 않는다.
+func WithLegacySecret(secret []byte, window time.Duration) Option {
+	return func(r *KeyRing) {
+		if len(secret) > 0 && window > 0 {
+			r.legacy = NewHMACKey(secret)
+			r.legacyWindow = window
+		}
+	}
+}
+
+// WithRetention 은퇴한 키를 검증용으로 남겨 두는 기간. 0 이하이면 기본값(14일)을 쓴다.
+func WithRetention(d time.Duration) Option {
+	return func(r *KeyRing) {
+		if d > 0 {
+			r.retention = d
+		}
+	}
+}
+
+// NewKeyRing 저장소에서 키를 읽어 KeyRing 을 만든다.
+func NewKeyRing(ctx context.Context, store KeyStore, opts ...Option) (*KeyRing, error) {
+	r := &KeyRing{
+		store:     store,
+		retention: defaultRetention,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 저장소에서 키를 다시 읽는다. 실패하면 기존 키를 그대로 유지한다.
+func (r *KeyRing) Reload(ctx context.Context) error {
+	stored, err := r.store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("auth: load signing keys: %w", err)
+	}
+
+	now := time.Now()
+	keys := make(map[string]*SigningKey)
+	var signing *SigningKey
+	var firstActivated time.Time
+	for _, key := range stored {
+		if !key.ActivatedAt.IsZero() && !key.ActivatedAt.After(now) &&
+			(firstActivated.IsZero() || key.ActivatedAt.Before(firstActivated)) {
+			firstActivated = key.ActivatedAt
+		}
+		if key.IsRetired(now) && now.Sub(key.RetiredAt) > r.retention {
+			continue
+		}
+		keys[key.ID] = key
+		if key.IsActive(now) && (signing == nil || key.ActivatedAt.After(signing.ActivatedAt)) {
+			signing = key
+		}
+	}
+	if signing == nil {
+		return errors.New("auth: no active asymmetric signing key; create and promote one with keyctl or set signing_key_file")
+	}
+	var legacyUntil time.Time
+	if r.legacy != nil {
+		legacyUntil = firstActivated.Add(r.legacyWindow)
+	}
+
+	r.mu.Lock()
+	r.signing = signing
+	r.keys = keys
+	r.legacyUntil = legacyUntil
+	r.mu.Unlock()
+	return nil
+}
+
+// Watch interval 마다 저장소를 다시 읽어 다른 인스턴스에서 승격한 키를 반영한다.
+// ctx 가 끝날 때까지 블록된다.
+func (r *KeyRing) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			previous := r.SigningKey().ID
+			if err := r.Reload(ctx); err != nil {
+				slog.Error("Failed to reload signing keys", slog.String("error", err.Error()))
+				continue
+			}
+			if current := r.SigningKey(); current.ID != previous {
+				slog.Info("Signing key rotated",
+					slog.String("previous_kid", previous),
+					slog.String("kid", current.ID),
+					slog.String("alg", current.Method.Alg()))
+			}
+		}
+	}
+}
+
+// SigningKey 현재 서명 키
+func (r *KeyRing) SigningKey() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.signing
+}
+
+// Sign 현재 서명 키로 클레임에 서명한다. 헤더에 kid 를 넣는다.
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	signing := r.SigningKey()
+	token := jwt.NewWithClaims(signing.Method, claims)
+	token.Header["kid"] = signing.ID
+	return token.SignedString(signing.Private)
+}
+
+// LegacyUntil 공유 비밀키(HS256) 토큰을 검증하는 마지막 시각. 받지 않으면 zero 이다.
+func (r *KeyRing) LegacyUntil() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.legacyUntil
+}
+
+// Parse 토큰을 검증하고 claims 에 채운다.
+// 공유 비밀키 토큰은 전환 기간(LegacyUntil) 안에서만 받는다.
+func (r *KeyRing) Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	r.mu.RLock()
+	keys := r.keys
+	var legacy *SigningKey
+	if r.legacy != nil && time.Now().Before(r.legacyUntil) {
+		legacy = r.legacy
+	}
+	r.mu.RUnlock()
+
+	algs := algorithms(keys)
+	if legacy != nil {
+		algs = append(algs, legacy.Method.Alg())
+	}
+	opts = append([]jwt.ParserOption{jwt.WithValidMethods(algs)}, opts...)
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return keyfunc(keys, legacy, t)
+	}, opts...)
+	return err
+}
+
+// keyfunc kid 로 검증 키를 고른다. kid 가 없거나 hs256 인 토큰은 공유 비밀키 토큰으로 보고,
+// 전환 기간이 지나 legacy 가 nil 이면 거부한다.
+func keyfunc(keys map[string]*SigningKey, legacy *SigningKey, t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := keys[kid]
+	if kid == "" || kid == hmacKeyID {
+		key, ok = legacy, legacy != nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", t.Method.Alg(), kid)
+	}
+	return key.Public, nil
+}
+
+func algorithms(keys map[string]*SigningKey) []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// JWKS 검증용 공개키 목록. 대기 중인 키도 미리 공개하여 승격 전에 캐시에 들어가게 한다.
+// 공유 비밀키는 포함하지 않는다.
+func (r *KeyRing) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range r.keys {
+		if key.IsSymmetric() {
+			continue
+		}
+		jwk, err := key.JWK()
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// memoryKeyStore 메모리에 둔 키 목록. Load 만 쓴다.
+type memoryKeyStore []*SigningKey
+
+func (s memoryKeyStore) Load(ctx context.Context) ([]*SigningKey, error) { return s, nil }
+func (s memoryKeyStore) Add(ctx context.Context, key *SigningKey) error  { return nil }
+func (s memoryKeyStore) Promote(ctx context.Context, kid string) error   { return nil }
+
+func testClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   "user",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func generateKey(t *testing.T) *SigningKey {
+	t.Helper()
+	key, err := GenerateSigningKey("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func jwksKids(set JWKS) map[string]bool {
+	kids := make(map[string]bool)
+	for _, key := range set.Keys {
+		kids[key.Kid] = true
+	}
+	return kids
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	ctx := context.Background()
+	store := NewDirKeyStore(t.TempDir())
+	first := generateKey(t)
+	if err := store.Add(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Promote(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	ring, err := NewKeyRing(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := ring.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 대기 중인 키는 승격 전에 JWKS 로 공개되지만 서명에는 쓰이지 않는다.
+	next := generateKey(t)
+	if err := store.Add(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if kids := jwksKids(ring.JWKS()); !kids[first.ID] || !kids[next.ID] {
+		t.Errorf("JWKS kids = %v, want both keys", kids)
+	}
+	if ring.SigningKey().ID != first.ID {
+		t.Errorf("pending key %s is signing", next.ID)
+	}
+
+	// 승격 후에는 새 키로 서명하고, 은퇴한 키로 서명된 토큰도 계속 검증한다.
+	if err := store.Promote(ctx, next.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ring.SigningKey().ID != next.ID {
+		t.Fatalf("signing key = %s, want %s", ring.SigningKey().ID, next.ID)
+	}
+	newToken, err := ring.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, token := range map[string]string{"retired key": oldToken, "active key": newToken} {
+		if err := ring.Parse(token, &jwt.RegisteredClaims{}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// 보존 기간이 지난 키는 JWKS 와 검증에서 빠진다.
+	expired, err := NewKeyRing(ctx, store, WithRetention(time.Nanosecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kids := jwksKids(expired.JWKS()); kids[first.ID] || !kids[next.ID] {
+		t.Errorf("JWKS kids after retention = %v, want only %s", kids, next.ID)
+	}
+	if err := expired.Parse(oldToken, &jwt.RegisteredClaims{}); err == nil {
+		t.Error("token signed by a key past retention was accepted")
+	}
+	if err := expired.Parse(newToken, &jwt.RegisteredClaims{}); err != nil {
+		t.Errorf("active key: %v", err)
+	}
+}
+
+func TestKeyRingNoActiveKey(t *testing.T) {
+	pending := generateKey(t)
+	if _, err := NewKeyRing(context.Background(), memoryKeyStore{pending}); err == nil {
+		t.Fatal("key ring without an active key was created")
+	}
+}
+
+func TestKeyRingLegacyWindow(t *testing.T) {
+	secret := []byte("legacy shared secret")
+	legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := generateKey(t)
+	key.ActivatedAt = time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		accept bool
+	}{
+		{"within window", []Option{WithLegacySecret(secret, 2*time.Hour)}, true},
+		{"after window", []Option{WithLegacySecret(secret, 30*time.Minute)}, false},
+		{"no legacy secret", nil, false},
+		{"wrong secret", []Option{WithLegacySecret([]byte("other secret"), 2*time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := NewKeyRing(context.Background(), memoryKeyStore{key}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ring.Parse(legacyToken, &jwt.RegisteredClaims{})
+			if got := err == nil; got != tt.accept {
+				t.Errorf("accepted = %v (err %v), want %v", got, err, tt.accept)
+			}
+		})
+	}
+
+	// 비대칭 키의 kid 를 단 HS256 토큰은 공개키를 비밀키로 쓰는 공격이므로 거부한다.
+	ring, err := NewKeyRing(context.Background(), memoryKeyStore{key}, WithLegacySecret(secret, 2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = key.ID
+	forgedToken, err := forged.SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Parse(forgedToken, &jwt.RegisteredClaims{}); err == nil {
+		t.Error("HS256 token with an asymmetric kid was accepted")
+	}
+}
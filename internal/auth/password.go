@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnsupportedHash 알 수 없는 형식의 비밀번호 해시
+var ErrUnsupportedHash = errors.New("unsupported password hash format")
+
+// Argon2Params argon2id 파라미터. Memory 는 KiB 단위이다.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params OWASP 권장값 기준 기본 파라미터 (64MiB, 3회, 병렬 2)
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHasher 새 비밀번호는 argon2id(PHC 문자열)로 해시하고,
+// 기존 bcrypt 해시도 검증한다.
+type PasswordHasher struct {
+	params Argon2Params
+	dummy  string // 사용자가 없을 때 비교할 해시. 응답 시간으로 가입 여부가 드러나지 않게 한다.
+}
+
+// NewPasswordHasher 0 인 파라미터는 DefaultArgon2Params 값으로 채운다.
+func NewPasswordHasher(params Argon2Params) *PasswordHasher {
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2Params.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2Params.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2Params.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2Params.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2Params.KeyLength
+	}
+	h := &PasswordHasher{params: params}
+	// 해시 실패는 난수 생성 실패뿐이다. 이 경우 VerifyDummy 는 비교 없이 반환한다.
+	h.dummy, _ = h.Hash("dummy-password-for-timing")
+	return h
+}
+
+// VerifyDummy 존재하지 않는 사용자에 대해서도 Verify 와 비슷한 시간이 걸리도록 더미 해시와 비교한다.
+func (h *PasswordHasher) VerifyDummy(password string) {
+	if h.dummy != "" {
+		h.Verify(password, h.dummy)
+	}
+}
+
+// Hash $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash> 형식의 해시를 만든다.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify 비밀번호가 해시와 일치하는지 확인한다.
+// needsRehash 는 일치했지만 bcrypt 이거나 현재 설정보다 약한 파라미터로 만든 해시일 때 true 이다.
+func (h *PasswordHasher) Verify(password, encoded string) (ok, needsRehash bool, err error) {
+	switch {
+	case encoded == "":
+		// 비밀번호 없이 가입한 (소셜 로그인) 사용자
+		h.VerifyDummy(password)
+		return false, false, nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2Hash(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, false, nil
+		}
+		return true, h.weaker(params, uint32(len(salt)), uint32(len(key))), nil
+	case strings.HasPrefix(encoded, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	default:
+		return false, false, ErrUnsupportedHash
+	}
+}
+
+// weaker 저장된 해시의 파라미터가 현재 설정보다 약한지
+func (h *PasswordHasher) weaker(params Argon2Params, saltLength, keyLength uint32) bool {
+	return params.Memory < h.params.Memory ||
+		params.Iterations < h.params.Iterations ||
+		params.Parallelism < h.params.Parallelism ||
+		saltLength < h.params.SaltLength ||
+		keyLength < h.params.KeyLength
+}
+
+// decodeArgon2Hash PHC 형식 argon2id 해시를 파싱한다.
+func decodeArgon2Hash(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("parse argon2 version: %w", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("parse argon2 params: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("decode argon2 salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("decode argon2 hash: %w", err)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
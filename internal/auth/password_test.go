@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params 테스트용 가벼운 파라미터
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	h := NewPasswordHasher(testArgon2Params)
+	argon2Hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(argon2Hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash format %q", argon2Hash)
+	}
+	weakHash, err := NewPasswordHasher(Argon2Params{Memory: 512, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		password    string
+		encoded     string
+		ok          bool
+		needsRehash bool
+		err         bool
+	}{
+		{"argon2id match", "correct horse", argon2Hash, true, false, false},
+		{"argon2id mismatch", "wrong horse", argon2Hash, false, false, false},
+		{"argon2id weaker params", "correct horse", weakHash, true, true, false},
+		{"argon2id weaker params mismatch", "wrong horse", weakHash, false, false, false},
+		{"bcrypt match", "correct horse", string(bcryptHash), true, true, false},
+		{"bcrypt mismatch", "wrong horse", string(bcryptHash), false, false, false},
+		{"no password", "correct horse", "", false, false, false},
+		{"unknown format", "correct horse", "plaintext", false, false, true},
+		{"malformed argon2id", "correct horse", "$argon2id$v=19$m=1024", false, false, true},
+		{"other argon2 version", "correct horse", strings.Replace(argon2Hash, "v=19", "v=16", 1), false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := h.Verify(tt.password, tt.encoded)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if ok != tt.ok || needsRehash != tt.needsRehash {
+				t.Errorf("Verify = (%v, %v), want (%v, %v)", ok, needsRehash, tt.ok, tt.needsRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      func(p Argon2Params) Argon2Params
+		needsRehash bool
+	}{
+		{"same params", func(p Argon2Params) Argon2Params { return p }, false},
+		{"stronger params", func(p Argon2Params) Argon2Params { p.Memory *= 2; p.Iterations++; return p }, false},
+		{"less memory", func(p Argon2Params) Argon2Params { p.Memory /= 2; return p }, true},
+		{"fewer iterations", func(p Argon2Params) Argon2Params { p.Iterations = 1; return p }, true},
+		{"less parallelism", func(p Argon2Params) Argon2Params { p.Parallelism = 1; return p }, true},
+		{"shorter salt", func(p Argon2Params) Argon2Params { p.SaltLength = 8; return p }, true},
+		{"shorter key", func(p Argon2Params) Argon2Params { p.KeyLength = 16; return p }, true},
+	}
+	current := Argon2Params{Memory: 1024, Iterations: 2, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+	h := NewPasswordHasher(current)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.stored(current)
+			encoded, err := NewPasswordHasher(params).Hash("password")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ok, needsRehash, err := h.Verify("password", encoded)
+			if err != nil || !ok {
+				t.Fatalf("Verify = (%v, %v), want match", ok, err)
+			}
+			if needsRehash != tt.needsRehash {
+				t.Errorf("needsRehash = %v, want %v", needsRehash, tt.needsRehash)
+			}
+		})
+	}
+}
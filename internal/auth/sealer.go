@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Sealer AES-256-GCM 으로 저장 데이터를 암호화한다.
+// 출력 형식은 nonce || ciphertext 이다.
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+// NewSealer base64 로 인코딩된 32바이트 키로 Sealer 를 만든다.
+func NewSealer(encodedKey string) (*Sealer, error) {
+	if encodedKey == "" {
+		return nil, errors.New("encryption key is not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealer{aead: aead}, nil
+}
+
+// Seal plaintext 를 암호화한다. associatedData 는 복호화 시 같은 값을 넘겨야 한다.
+func (s *Sealer) Seal(plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// Open Seal 로 암호화한 데이터를 복호화한다.
+func (s *Sealer) Open(sealed, associatedData []byte) ([]byte, error) {
+	size := s.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, errors.New("sealed data too short")
+	}
+	return s.aead.Open(nil, sealed[:size], sealed[size:], associatedData)
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/escape-ship/accountsrv/config"
+)
+
+// ErrKeyNotFound 저장소에 해당 kid 의 키가 없음
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// KeyStore 서명 키 저장소
+type KeyStore interface {
+	// Load 저장된 모든 키를 읽는다. (대기/활성/은퇴 포함)
+	Load(ctx context.Context) ([]*SigningKey, error)
+	// Add 새 키를 대기 상태로 저장한다.
+	Add(ctx context.Context, key *SigningKey) error
+	// Promote kid 키를 서명 키로 승격하고 기존 서명 키를 은퇴시킨다.
+	Promote(ctx context.Context, kid string) error
+}
+
+// NewKeyStore 설정(auth.key_store)에 맞는 키 저장소를 만든다.
+//   - "postgres": account.signing_keys 테이블 (개인키는 key_encryption_key 로 암호화)
+//   - "dir": key_dir 디렉터리의 <kid>.json 파일
+//   - "": signing_key_file 하나를 고정 서명 키로 사용 (회전 불가)
+func NewKeyStore(cfg config.Auth, db *sql.DB) (KeyStore, error) {
+	switch cfg.KeyStore {
+	case "postgres":
+		sealer, err := NewSealer(cfg.KeyEncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("auth: key_encryption_key: %w", err)
+		}
+		return NewPostgresKeyStore(db, sealer), nil
+	case "dir":
+		if cfg.KeyDir == "" {
+			return nil, errors.New("auth: key_dir must be set for dir key store")
+		}
+		return NewDirKeyStore(cfg.KeyDir), nil
+	case "":
+		return &staticKeyStore{path: cfg.SigningKeyFile}, nil
+	default:
+		return nil, fmt.Errorf("auth: unknown key_store %q", cfg.KeyStore)
+	}
+}
+
+// staticKeyStore signing_key_file 하나만 제공하는 읽기 전용 저장소
+type staticKeyStore struct {
+	path string
+}
+
+// Load 키 파일의 수정 시각을 활성화 시각으로 본다. 공유 비밀키 전환 기간이 재시작마다 늘어나지 않게 한다.
+func (s *staticKeyStore) Load(ctx context.Context) ([]*SigningKey, error) {
+	if s.path == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("stat signing key %s: %w", s.path, err)
+	}
+	key, err := LoadSigningKeyFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	key.ActivatedAt = info.ModTime()
+	if now := time.Now(); key.ActivatedAt.After(now) {
+		key.ActivatedAt = now
+	}
+	return []*SigningKey{key}, nil
+}
+
+func (s *staticKeyStore) Add(ctx context.Context, key *SigningKey) error {
+	return errors.New("static key store does not support adding keys; set auth.key_store")
+}
+
+func (s *staticKeyStore) Promote(ctx context.Context, kid string) error {
+	return errors.New("static key store does not support promoting keys; set auth.key_store")
+}
+
+// DirKeyStore 디렉터리에 키마다 <kid>.json 파일로 저장한다. 개인키는 평문 PEM 이므로
+// 디렉터리 권한으로 보호해야 한다.
+type DirKeyStore struct {
+	dir string
+}
+
+type keyFile struct {
+	Kid         string     `json:"kid"`
+	Alg         string     `json:"alg"`
+	PrivateKey  string     `json:"private_key"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+}
+
+func NewDirKeyStore(dir string) *DirKeyStore {
+	return &DirKeyStore{dir: dir}
+}
+
+func (s *DirKeyStore) Load(ctx context.Context) ([]*SigningKey, error) {
+	files, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*SigningKey, 0, len(files))
+	for _, f := range files {
+		key, err := ParseSigningKeyPEM([]byte(f.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", f.Kid, err)
+		}
+		if key.ID != f.Kid {
+			return nil, fmt.Errorf("key %s: kid does not match key material", f.Kid)
+		}
+		key.CreatedAt = f.CreatedAt
+		if f.ActivatedAt != nil {
+			key.ActivatedAt = *f.ActivatedAt
+		}
+		if f.RetiredAt != nil {
+			key.RetiredAt = *f.RetiredAt
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *DirKeyStore) Add(ctx context.Context, key *SigningKey) error {
+	privatePEM, err := key.MarshalPrivateKeyPEM()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	return s.write(&keyFile{
+		Kid:        key.ID,
+		Alg:        key.Method.Alg(),
+		PrivateKey: string(privatePEM),
+		CreatedAt:  key.CreatedAt,
+	})
+}
+
+func (s *DirKeyStore) Promote(ctx context.Context, kid string) error {
+	files, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var target *keyFile
+	for _, f := range files {
+		if f.Kid == kid {
+			target = f
+		}
+	}
+	if target == nil {
+		return ErrKeyNotFound
+	}
+	for _, f := range files {
+		if f.Kid != kid && f.ActivatedAt != nil && f.RetiredAt == nil {
+			f.RetiredAt = &now
+			if err := s.write(f); err != nil {
+				return err
+			}
+		}
+	}
+	target.ActivatedAt = &now
+	target.RetiredAt = nil
+	return s.write(target)
+}
+
+func (s *DirKeyStore) readAll() ([]*keyFile, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*keyFile, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f keyFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if f.Kid != strings.TrimSuffix(filepath.Base(path), ".json") {
+			return nil, fmt.Errorf("%s: file name does not match kid %s", path, f.Kid)
+		}
+		files = append(files, &f)
+	}
+	return files, nil
+}
+
+func (s *DirKeyStore) write(f *keyFile) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	// 임시 파일에 쓴 뒤 rename 하여 읽는 쪽이 반쯤 쓰인 파일을 보지 않게 한다.
+	path := filepath.Join(s.dir, f.Kid+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
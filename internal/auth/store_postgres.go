@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+)
+
+// PostgresKeyStore account.signing_keys 테이블에 키를 저장한다.
+// 개인키는 Sealer 로 암호화하며 kid 를 associated data 로 묶어 다른 행으로 옮겨 쓸 수 없게 한다.
+type PostgresKeyStore struct {
+	db     *sql.DB
+	sealer *Sealer
+}
+
+func NewPostgresKeyStore(db *sql.DB, sealer *Sealer) *PostgresKeyStore {
+	return &PostgresKeyStore{db: db, sealer: sealer}
+}
+
+func (s *PostgresKeyStore) Load(ctx context.Context) ([]*SigningKey, error) {
+	rows, err := postgresql.New(s.db).ListSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*SigningKey, 0, len(rows))
+	for _, row := range rows {
+		privatePEM, err := s.sealer.Open(row.PrivateKey, []byte(row.Kid))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: decrypt private key: %w", row.Kid, err)
+		}
+		key, err := ParseSigningKeyPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", row.Kid, err)
+		}
+		if key.ID != row.Kid {
+			return nil, fmt.Errorf("key %s: kid does not match key material", row.Kid)
+		}
+		key.CreatedAt = row.CreatedAt
+		if row.ActivatedAt.Valid {
+			key.ActivatedAt = row.ActivatedAt.Time
+		}
+		if row.RetiredAt.Valid {
+			key.RetiredAt = row.RetiredAt.Time
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *PostgresKeyStore) Add(ctx context.Context, key *SigningKey) error {
+	privatePEM, err := key.MarshalPrivateKeyPEM()
+	if err != nil {
+		return err
+	}
+	sealed, err := s.sealer.Seal(privatePEM, []byte(key.ID))
+	if err != nil {
+		return err
+	}
+	return postgresql.New(s.db).InsertSigningKey(ctx, postgresql.InsertSigningKeyParams{
+		Kid:        key.ID,
+		Algorithm:  key.Method.Alg(),
+		PrivateKey: sealed,
+		CreatedAt:  key.CreatedAt,
+	})
+}
+
+func (s *PostgresKeyStore) Promote(ctx context.Context, kid string) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			err = tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+
+	qtx := postgresql.New(tx)
+	n, err := qtx.ActivateSigningKey(ctx, kid)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrKeyNotFound
+	}
+	return qtx.RetireActiveSigningKeys(ctx, kid)
+}
@@ -6,23 +6,144 @@ package postgresql
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type AccountRefreshToken struct {
+type AccountEmailVerificationToken struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type AccountOutbox struct {
+	ID            uuid.UUID       `json:"id"`
+	Kind          string          `json:"kind"`
+	Recipient     string          `json:"recipient"`
+	Locale        string          `json:"locale"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int32           `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     sql.NullString  `json:"last_error"`
+	SentAt        sql.NullTime    `json:"sent_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type AccountPasswordResetToken struct {
 	ID        uuid.UUID    `json:"id"`
 	UserID    uuid.UUID    `json:"user_id"`
-	Token     string       `json:"token"`
+	TokenHash string       `json:"token_hash"`
 	ExpiresAt time.Time    `json:"expires_at"`
-	CreatedAt sql.NullTime `json:"created_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type AccountProviderToken struct {
+	IdentityID       uuid.UUID    `json:"identity_id"`
+	AccessToken      []byte       `json:"access_token"`
+	RefreshToken     []byte       `json:"refresh_token"`
+	ExpiresAt        sql.NullTime `json:"expires_at"`
+	RefreshExpiresAt sql.NullTime `json:"refresh_expires_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+type AccountRecoveryCode struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type AccountRefreshToken struct {
+	ID         uuid.UUID     `json:"id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  sql.NullTime  `json:"created_at"`
+	FamilyID   uuid.UUID     `json:"family_id"`
+	ConsumedAt sql.NullTime  `json:"consumed_at"`
+	RevokedAt  sql.NullTime  `json:"revoked_at"`
+	SessionID  uuid.NullUUID `json:"session_id"`
+	TokenHash  string        `json:"token_hash"`
+}
+
+type AccountRole struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AccountSession struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	DeviceName string       `json:"device_name"`
+	UserAgent  string       `json:"user_agent"`
+	IpAddress  string       `json:"ip_address"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastSeenAt time.Time    `json:"last_seen_at"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
+type AccountSigningKey struct {
+	Kid         string       `json:"kid"`
+	Algorithm   string       `json:"algorithm"`
+	PrivateKey  []byte       `json:"private_key"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ActivatedAt sql.NullTime `json:"activated_at"`
+	RetiredAt   sql.NullTime `json:"retired_at"`
 }
 
 type AccountUser struct {
-	ID           uuid.UUID    `json:"id"`
-	Email        string       `json:"email"`
-	PasswordHash string       `json:"password_hash"`
-	CreatedAt    sql.NullTime `json:"created_at"`
-	UpdatedAt    sql.NullTime `json:"updated_at"`
+	ID              uuid.UUID    `json:"id"`
+	Email           string       `json:"email"`
+	PasswordHash    string       `json:"password_hash"`
+	CreatedAt       sql.NullTime `json:"created_at"`
+	UpdatedAt       sql.NullTime `json:"updated_at"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+}
+
+type AccountUserIdentity struct {
+	ID             uuid.UUID    `json:"id"`
+	UserID         uuid.UUID    `json:"user_id"`
+	Provider       string       `json:"provider"`
+	ProviderUserID string       `json:"provider_user_id"`
+	Email          string       `json:"email"`
+	CreatedAt      time.Time    `json:"created_at"`
+	LastLoginAt    sql.NullTime `json:"last_login_at"`
+}
+
+type AccountUserRole struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role"`
+	GrantedAt time.Time `json:"granted_at"`
+}
+
+type AccountUserTotp struct {
+	UserID       uuid.UUID    `json:"user_id"`
+	Secret       []byte       `json:"secret"`
+	LastUsedStep int64        `json:"last_used_step"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type AccountWebauthnCredential struct {
+	ID              uuid.UUID    `json:"id"`
+	UserID          uuid.UUID    `json:"user_id"`
+	CredentialID    []byte       `json:"credential_id"`
+	PublicKey       []byte       `json:"public_key"`
+	AttestationType string       `json:"attestation_type"`
+	Aaguid          []byte       `json:"aaguid"`
+	SignCount       int64        `json:"sign_count"`
+	Transports      string       `json:"transports"`
+	BackupEligible  bool         `json:"backup_eligible"`
+	BackupState     bool         `json:"backup_state"`
+	Name            string       `json:"name"`
+	CreatedAt       time.Time    `json:"created_at"`
+	LastUsedAt      sql.NullTime `json:"last_used_at"`
 }
@@ -7,52 +7,634 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const activateSigningKey = `-- name: ActivateSigningKey :execrows
+UPDATE account.signing_keys
+SET activated_at = CURRENT_TIMESTAMP, retired_at = NULL
+WHERE kid = $1
+`
+
+func (q *Queries) ActivateSigningKey(ctx context.Context, kid string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, activateSigningKey, kid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimOutboxMessages = `-- name: ClaimOutboxMessages :many
+SELECT id, kind, recipient, locale, payload, attempts FROM account.outbox
+WHERE sent_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP AND attempts < $1::int
+ORDER BY next_attempt_at
+LIMIT $2::int
+FOR UPDATE SKIP LOCKED
+`
+
+type ClaimOutboxMessagesParams struct {
+	MaxAttempts int32 `json:"max_attempts"`
+	BatchSize   int32 `json:"batch_size"`
+}
+
+type ClaimOutboxMessagesRow struct {
+	ID        uuid.UUID       `json:"id"`
+	Kind      string          `json:"kind"`
+	Recipient string          `json:"recipient"`
+	Locale    string          `json:"locale"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int32           `json:"attempts"`
+}
+
+func (q *Queries) ClaimOutboxMessages(ctx context.Context, arg ClaimOutboxMessagesParams) ([]ClaimOutboxMessagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimOutboxMessages, arg.MaxAttempts, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimOutboxMessagesRow
+	for rows.Next() {
+		var i ClaimOutboxMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Recipient,
+			&i.Locale,
+			&i.Payload,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimUserIdentity = `-- name: ClaimUserIdentity :exec
+UPDATE account.user_identities
+SET provider_user_id = $2
+WHERE id = $1
+`
+
+type ClaimUserIdentityParams struct {
+	ID             uuid.UUID `json:"id"`
+	ProviderUserID string    `json:"provider_user_id"`
+}
+
+func (q *Queries) ClaimUserIdentity(ctx context.Context, arg ClaimUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, claimUserIdentity, arg.ID, arg.ProviderUserID)
+	return err
+}
+
+const confirmUserTOTP = `-- name: ConfirmUserTOTP :exec
+UPDATE account.user_totp
+SET confirmed_at = CURRENT_TIMESTAMP, last_used_step = $2
+WHERE user_id = $1
+`
+
+type ConfirmUserTOTPParams struct {
+	UserID       uuid.UUID `json:"user_id"`
+	LastUsedStep int64     `json:"last_used_step"`
+}
+
+func (q *Queries) ConfirmUserTOTP(ctx context.Context, arg ConfirmUserTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, confirmUserTOTP, arg.UserID, arg.LastUsedStep)
+	return err
+}
+
+const consumeRefreshToken = `-- name: ConsumeRefreshToken :exec
+UPDATE account.refresh_tokens
+SET consumed_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) ConsumeRefreshToken(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, consumeRefreshToken, id)
+	return err
+}
+
+const deleteFinishedOutboxMessages = `-- name: DeleteFinishedOutboxMessages :execrows
+DELETE FROM account.outbox
+WHERE created_at < $1::timestamp
+  AND (sent_at IS NOT NULL OR attempts >= $2::int)
+`
+
+type DeleteFinishedOutboxMessagesParams struct {
+	Before      time.Time `json:"before"`
+	MaxAttempts int32     `json:"max_attempts"`
+}
+
+func (q *Queries) DeleteFinishedOutboxMessages(ctx context.Context, arg DeleteFinishedOutboxMessagesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFinishedOutboxMessages, arg.Before, arg.MaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM account.recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const deleteUserIdentity = `-- name: DeleteUserIdentity :execrows
+DELETE FROM account.user_identities
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteUserIdentityParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteUserIdentity(ctx context.Context, arg DeleteUserIdentityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserIdentity, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserTOTP = `-- name: DeleteUserTOTP :exec
+DELETE FROM account.user_totp
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserTOTP(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserTOTP, userID)
+	return err
+}
+
+const getEmailVerificationTokenForUpdate = `-- name: GetEmailVerificationTokenForUpdate :one
+SELECT id, user_id, expires_at, used_at FROM account.email_verification_tokens
+WHERE token_hash = $1
+FOR UPDATE
+`
+
+type GetEmailVerificationTokenForUpdateRow struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+func (q *Queries) GetEmailVerificationTokenForUpdate(ctx context.Context, tokenHash string) (GetEmailVerificationTokenForUpdateRow, error) {
+	row := q.db.QueryRowContext(ctx, getEmailVerificationTokenForUpdate, tokenHash)
+	var i GetEmailVerificationTokenForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+	)
+	return i, err
+}
+
+const getPasswordResetTokenForUpdate = `-- name: GetPasswordResetTokenForUpdate :one
+SELECT id, user_id, expires_at, used_at FROM account.password_reset_tokens
+WHERE token_hash = $1
+FOR UPDATE
+`
+
+type GetPasswordResetTokenForUpdateRow struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+func (q *Queries) GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (GetPasswordResetTokenForUpdateRow, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordResetTokenForUpdate, tokenHash)
+	var i GetPasswordResetTokenForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+	)
+	return i, err
+}
+
+const getProviderTokenForUpdate = `-- name: GetProviderTokenForUpdate :one
+SELECT t.identity_id, t.access_token, t.refresh_token, t.expires_at, t.refresh_expires_at, t.updated_at
+FROM account.provider_tokens t
+JOIN account.user_identities i ON i.id = t.identity_id
+WHERE i.user_id = $1 AND i.provider = $2
+FOR UPDATE OF t
+`
+
+type GetProviderTokenForUpdateParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Provider string    `json:"provider"`
+}
+
+func (q *Queries) GetProviderTokenForUpdate(ctx context.Context, arg GetProviderTokenForUpdateParams) (AccountProviderToken, error) {
+	row := q.db.QueryRowContext(ctx, getProviderTokenForUpdate, arg.UserID, arg.Provider)
+	var i AccountProviderToken
+	err := row.Scan(
+		&i.IdentityID,
+		&i.AccessToken,
+		&i.RefreshToken,
+		&i.ExpiresAt,
+		&i.RefreshExpiresAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRefreshTokenForUpdate = `-- name: GetRefreshTokenForUpdate :one
+SELECT id, user_id, family_id, session_id, expires_at, consumed_at, revoked_at
+FROM account.refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE
+`
+
+type GetRefreshTokenForUpdateRow struct {
+	ID         uuid.UUID     `json:"id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	FamilyID   uuid.UUID     `json:"family_id"`
+	SessionID  uuid.NullUUID `json:"session_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	ConsumedAt sql.NullTime  `json:"consumed_at"`
+	RevokedAt  sql.NullTime  `json:"revoked_at"`
+}
+
+func (q *Queries) GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (GetRefreshTokenForUpdateRow, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenForUpdate, tokenHash)
+	var i GetRefreshTokenForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FamilyID,
+		&i.SessionID,
+		&i.ExpiresAt,
+		&i.ConsumedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getRole = `-- name: GetRole :one
+SELECT name, description, created_at FROM account.roles
+WHERE name = $1
+`
+
+func (q *Queries) GetRole(ctx context.Context, name string) (AccountRole, error) {
+	row := q.db.QueryRowContext(ctx, getRole, name)
+	var i AccountRole
+	err := row.Scan(&i.Name, &i.Description, &i.CreatedAt)
+	return i, err
+}
+
+const getSession = `-- name: GetSession :one
+SELECT id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+FROM account.sessions
+WHERE id = $1
+`
+
+func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (AccountSession, error) {
+	row := q.db.QueryRowContext(ctx, getSession, id)
+	var i AccountSession
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.CreatedAt,
+		&i.LastSeenAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash
+SELECT id, email, password_hash, email_verified_at
 FROM account.users
-WHERE email = $1
+WHERE email = $1 AND email <> ''
 `
 
 type GetUserByEmailRow struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"password_hash"`
+	ID              uuid.UUID    `json:"id"`
+	Email           string       `json:"email"`
+	PasswordHash    string       `json:"password_hash"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
 }
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
 	var i GetUserByEmailRow
-	err := row.Scan(&i.ID, &i.Email, &i.PasswordHash)
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+	)
 	return i, err
 }
 
-const insertRefreshToken = `-- name: InsertRefreshToken :exec
-INSERT INTO account.refresh_tokens (id, user_id, token, expires_at)
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, email, password_hash, email_verified_at FROM account.users
+WHERE id = $1
+`
+
+type GetUserByIDRow struct {
+	ID              uuid.UUID    `json:"id"`
+	Email           string       `json:"email"`
+	PasswordHash    string       `json:"password_hash"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+}
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i GetUserByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const getUserByIDForUpdate = `-- name: GetUserByIDForUpdate :one
+SELECT id, email, password_hash, email_verified_at FROM account.users
+WHERE id = $1
+FOR UPDATE
+`
+
+type GetUserByIDForUpdateRow struct {
+	ID              uuid.UUID    `json:"id"`
+	Email           string       `json:"email"`
+	PasswordHash    string       `json:"password_hash"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+}
+
+func (q *Queries) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (GetUserByIDForUpdateRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIDForUpdate, id)
+	var i GetUserByIDForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+SELECT id, user_id, provider, provider_user_id, email, created_at, last_login_at
+FROM account.user_identities
+WHERE provider = $1 AND provider_user_id = $2
+`
+
+type GetUserIdentityParams struct {
+	Provider       string `json:"provider"`
+	ProviderUserID string `json:"provider_user_id"`
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (AccountUserIdentity, error) {
+	row := q.db.QueryRowContext(ctx, getUserIdentity, arg.Provider, arg.ProviderUserID)
+	var i AccountUserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Provider,
+		&i.ProviderUserID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const getUserTOTP = `-- name: GetUserTOTP :one
+SELECT user_id, secret, last_used_step, confirmed_at, created_at FROM account.user_totp
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserTOTP(ctx context.Context, userID uuid.UUID) (AccountUserTotp, error) {
+	row := q.db.QueryRowContext(ctx, getUserTOTP, userID)
+	var i AccountUserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.LastUsedStep,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserTOTPForUpdate = `-- name: GetUserTOTPForUpdate :one
+SELECT user_id, secret, last_used_step, confirmed_at, created_at FROM account.user_totp
+WHERE user_id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetUserTOTPForUpdate(ctx context.Context, userID uuid.UUID) (AccountUserTotp, error) {
+	row := q.db.QueryRowContext(ctx, getUserTOTPForUpdate, userID)
+	var i AccountUserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.LastUsedStep,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const grantUserRole = `-- name: GrantUserRole :exec
+INSERT INTO account.user_roles (user_id, role)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type GrantUserRoleParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"`
+}
+
+func (q *Queries) GrantUserRole(ctx context.Context, arg GrantUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, grantUserRole, arg.UserID, arg.Role)
+	return err
+}
+
+const insertEmailVerificationToken = `-- name: InsertEmailVerificationToken :exec
+INSERT INTO account.email_verification_tokens (id, user_id, token_hash, expires_at)
 VALUES ($1, $2, $3, $4)
 `
 
-type InsertRefreshTokenParams struct {
+type InsertEmailVerificationTokenParams struct {
 	ID        uuid.UUID `json:"id"`
 	UserID    uuid.UUID `json:"user_id"`
-	Token     string    `json:"token"`
+	TokenHash string    `json:"token_hash"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+func (q *Queries) InsertEmailVerificationToken(ctx context.Context, arg InsertEmailVerificationTokenParams) error {
+	_, err := q.db.ExecContext(ctx, insertEmailVerificationToken,
+		arg.ID,
+		arg.UserID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const insertOutboxMessage = `-- name: InsertOutboxMessage :exec
+INSERT INTO account.outbox (id, kind, recipient, locale, payload)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type InsertOutboxMessageParams struct {
+	ID        uuid.UUID       `json:"id"`
+	Kind      string          `json:"kind"`
+	Recipient string          `json:"recipient"`
+	Locale    string          `json:"locale"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) InsertOutboxMessage(ctx context.Context, arg InsertOutboxMessageParams) error {
+	_, err := q.db.ExecContext(ctx, insertOutboxMessage,
+		arg.ID,
+		arg.Kind,
+		arg.Recipient,
+		arg.Locale,
+		arg.Payload,
+	)
+	return err
+}
+
+const insertPasswordResetToken = `-- name: InsertPasswordResetToken :exec
+INSERT INTO account.password_reset_tokens (id, user_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type InsertPasswordResetTokenParams struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) InsertPasswordResetToken(ctx context.Context, arg InsertPasswordResetTokenParams) error {
+	_, err := q.db.ExecContext(ctx, insertPasswordResetToken,
+		arg.ID,
+		arg.UserID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const insertRecoveryCode = `-- name: InsertRecoveryCode :exec
+INSERT INTO account.recovery_codes (id, user_id, code_hash)
+VALUES ($1, $2, $3)
+`
+
+type InsertRecoveryCodeParams struct {
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	CodeHash string    `json:"code_hash"`
+}
+
+func (q *Queries) InsertRecoveryCode(ctx context.Context, arg InsertRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, insertRecoveryCode, arg.ID, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const insertRefreshToken = `-- name: InsertRefreshToken :exec
+INSERT INTO account.refresh_tokens (id, user_id, family_id, session_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type InsertRefreshTokenParams struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	FamilyID  uuid.UUID     `json:"family_id"`
+	SessionID uuid.NullUUID `json:"session_id"`
+	TokenHash string        `json:"token_hash"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
 func (q *Queries) InsertRefreshToken(ctx context.Context, arg InsertRefreshTokenParams) error {
 	_, err := q.db.ExecContext(ctx, insertRefreshToken,
 		arg.ID,
 		arg.UserID,
-		arg.Token,
+		arg.FamilyID,
+		arg.SessionID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const insertSession = `-- name: InsertSession :exec
+INSERT INTO account.sessions (id, user_id, device_name, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type InsertSessionParams struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IpAddress  string    `json:"ip_address"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (q *Queries) InsertSession(ctx context.Context, arg InsertSessionParams) error {
+	_, err := q.db.ExecContext(ctx, insertSession,
+		arg.ID,
+		arg.UserID,
+		arg.DeviceName,
+		arg.UserAgent,
+		arg.IpAddress,
 		arg.ExpiresAt,
 	)
 	return err
 }
 
+const insertSigningKey = `-- name: InsertSigningKey :exec
+INSERT INTO account.signing_keys (kid, algorithm, private_key, created_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type InsertSigningKeyParams struct {
+	Kid        string    `json:"kid"`
+	Algorithm  string    `json:"algorithm"`
+	PrivateKey []byte    `json:"private_key"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (q *Queries) InsertSigningKey(ctx context.Context, arg InsertSigningKeyParams) error {
+	_, err := q.db.ExecContext(ctx, insertSigningKey,
+		arg.Kid,
+		arg.Algorithm,
+		arg.PrivateKey,
+		arg.CreatedAt,
+	)
+	return err
+}
+
 const insertUser = `-- name: InsertUser :one
 INSERT INTO account.users (id, email, password_hash)
 VALUES ($1, $2, $3)
@@ -71,3 +653,602 @@ func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) (uuid.UU
 	err := row.Scan(&id)
 	return id, err
 }
+
+const insertUserIdentity = `-- name: InsertUserIdentity :exec
+INSERT INTO account.user_identities (id, user_id, provider, provider_user_id, email)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type InsertUserIdentityParams struct {
+	ID             uuid.UUID `json:"id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Provider       string    `json:"provider"`
+	ProviderUserID string    `json:"provider_user_id"`
+	Email          string    `json:"email"`
+}
+
+func (q *Queries) InsertUserIdentity(ctx context.Context, arg InsertUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, insertUserIdentity,
+		arg.ID,
+		arg.UserID,
+		arg.Provider,
+		arg.ProviderUserID,
+		arg.Email,
+	)
+	return err
+}
+
+const insertWebAuthnCredential = `-- name: InsertWebAuthnCredential :exec
+INSERT INTO account.webauthn_credentials (
+    id, user_id, credential_id, public_key, attestation_type, aaguid,
+    sign_count, transports, backup_eligible, backup_state, name
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+`
+
+type InsertWebAuthnCredentialParams struct {
+	ID              uuid.UUID `json:"id"`
+	UserID          uuid.UUID `json:"user_id"`
+	CredentialID    []byte    `json:"credential_id"`
+	PublicKey       []byte    `json:"public_key"`
+	AttestationType string    `json:"attestation_type"`
+	Aaguid          []byte    `json:"aaguid"`
+	SignCount       int64     `json:"sign_count"`
+	Transports      string    `json:"transports"`
+	BackupEligible  bool      `json:"backup_eligible"`
+	BackupState     bool      `json:"backup_state"`
+	Name            string    `json:"name"`
+}
+
+func (q *Queries) InsertWebAuthnCredential(ctx context.Context, arg InsertWebAuthnCredentialParams) error {
+	_, err := q.db.ExecContext(ctx, insertWebAuthnCredential,
+		arg.ID,
+		arg.UserID,
+		arg.CredentialID,
+		arg.PublicKey,
+		arg.AttestationType,
+		arg.Aaguid,
+		arg.SignCount,
+		arg.Transports,
+		arg.BackupEligible,
+		arg.BackupState,
+		arg.Name,
+	)
+	return err
+}
+
+const invalidateEmailVerificationTokens = `-- name: InvalidateEmailVerificationTokens :exec
+UPDATE account.email_verification_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidateEmailVerificationTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, invalidateEmailVerificationTokens, userID)
+	return err
+}
+
+const invalidatePasswordResetTokens = `-- name: InvalidatePasswordResetTokens :exec
+UPDATE account.password_reset_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, invalidatePasswordResetTokens, userID)
+	return err
+}
+
+const listActiveSessionsByUser = `-- name: ListActiveSessionsByUser :many
+SELECT id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+FROM account.sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+ORDER BY last_seen_at DESC
+`
+
+func (q *Queries) ListActiveSessionsByUser(ctx context.Context, userID uuid.UUID) ([]AccountSession, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveSessionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountSession
+	for rows.Next() {
+		var i AccountSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.DeviceName,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSigningKeys = `-- name: ListSigningKeys :many
+SELECT kid, algorithm, private_key, created_at, activated_at, retired_at
+FROM account.signing_keys
+ORDER BY created_at
+`
+
+func (q *Queries) ListSigningKeys(ctx context.Context) ([]AccountSigningKey, error) {
+	rows, err := q.db.QueryContext(ctx, listSigningKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountSigningKey
+	for rows.Next() {
+		var i AccountSigningKey
+		if err := rows.Scan(
+			&i.Kid,
+			&i.Algorithm,
+			&i.PrivateKey,
+			&i.CreatedAt,
+			&i.ActivatedAt,
+			&i.RetiredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserIdentitiesByUser = `-- name: ListUserIdentitiesByUser :many
+SELECT id, user_id, provider, provider_user_id, email, created_at, last_login_at
+FROM account.user_identities
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListUserIdentitiesByUser(ctx context.Context, userID uuid.UUID) ([]AccountUserIdentity, error) {
+	rows, err := q.db.QueryContext(ctx, listUserIdentitiesByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountUserIdentity
+	for rows.Next() {
+		var i AccountUserIdentity
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Provider,
+			&i.ProviderUserID,
+			&i.Email,
+			&i.CreatedAt,
+			&i.LastLoginAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserRoles = `-- name: ListUserRoles :many
+SELECT role FROM account.user_roles
+WHERE user_id = $1
+ORDER BY role
+`
+
+func (q *Queries) ListUserRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listUserRoles, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebAuthnCredentialsByUser = `-- name: ListWebAuthnCredentialsByUser :many
+SELECT id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count, transports, backup_eligible, backup_state, name, created_at, last_used_at
+FROM account.webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListWebAuthnCredentialsByUser(ctx context.Context, userID uuid.UUID) ([]AccountWebauthnCredential, error) {
+	rows, err := q.db.QueryContext(ctx, listWebAuthnCredentialsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountWebauthnCredential
+	for rows.Next() {
+		var i AccountWebauthnCredential
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CredentialID,
+			&i.PublicKey,
+			&i.AttestationType,
+			&i.Aaguid,
+			&i.SignCount,
+			&i.Transports,
+			&i.BackupEligible,
+			&i.BackupState,
+			&i.Name,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :exec
+UPDATE account.users
+SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND email_verified_at IS NULL
+`
+
+func (q *Queries) MarkEmailVerified(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markEmailVerified, id)
+	return err
+}
+
+const markOutboxMessageFailed = `-- name: MarkOutboxMessageFailed :exec
+UPDATE account.outbox
+SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+WHERE id = $1
+`
+
+type MarkOutboxMessageFailedParams struct {
+	ID            uuid.UUID      `json:"id"`
+	LastError     sql.NullString `json:"last_error"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+}
+
+func (q *Queries) MarkOutboxMessageFailed(ctx context.Context, arg MarkOutboxMessageFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markOutboxMessageFailed, arg.ID, arg.LastError, arg.NextAttemptAt)
+	return err
+}
+
+const markOutboxMessageSent = `-- name: MarkOutboxMessageSent :exec
+UPDATE account.outbox
+SET attempts = attempts + 1, sent_at = CURRENT_TIMESTAMP, last_error = NULL, payload = '{}'
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxMessageSent(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markOutboxMessageSent, id)
+	return err
+}
+
+const retireActiveSigningKeys = `-- name: RetireActiveSigningKeys :exec
+UPDATE account.signing_keys
+SET retired_at = CURRENT_TIMESTAMP
+WHERE kid <> $1 AND activated_at IS NOT NULL AND retired_at IS NULL
+`
+
+func (q *Queries) RetireActiveSigningKeys(ctx context.Context, kid string) error {
+	_, err := q.db.ExecContext(ctx, retireActiveSigningKeys, kid)
+	return err
+}
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
+UPDATE account.refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE token_hash = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeRefreshTokenParams struct {
+	TokenHash string    `json:"token_hash"`
+	UserID    uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, arg.TokenHash, arg.UserID)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE account.refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE account.sessions
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeSessionParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeSessionRefreshTokens = `-- name: RevokeSessionRefreshTokens :exec
+UPDATE account.refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE session_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeSessionRefreshTokens(ctx context.Context, sessionID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, revokeSessionRefreshTokens, sessionID)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE account.refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND revoked_at IS NULL
+  AND (session_id IS NULL OR session_id <> $2::uuid)
+`
+
+type RevokeUserRefreshTokensParams struct {
+	UserID          uuid.UUID `json:"user_id"`
+	ExceptSessionID uuid.UUID `json:"except_session_id"`
+}
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, arg RevokeUserRefreshTokensParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, arg.UserID, arg.ExceptSessionID)
+	return err
+}
+
+const revokeUserRole = `-- name: RevokeUserRole :execrows
+DELETE FROM account.user_roles
+WHERE user_id = $1 AND role = $2
+`
+
+type RevokeUserRoleParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"`
+}
+
+func (q *Queries) RevokeUserRole(ctx context.Context, arg RevokeUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeUserRole, arg.UserID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :many
+UPDATE account.sessions
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND id <> $2::uuid AND revoked_at IS NULL
+RETURNING id
+`
+
+type RevokeUserSessionsParams struct {
+	UserID          uuid.UUID `json:"user_id"`
+	ExceptSessionID uuid.UUID `json:"except_session_id"`
+}
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, arg RevokeUserSessionsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, revokeUserSessions, arg.UserID, arg.ExceptSessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE account.sessions
+SET last_seen_at = CURRENT_TIMESTAMP, expires_at = $2
+WHERE id = $1
+`
+
+type TouchSessionParams struct {
+	ID        uuid.UUID `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) TouchSession(ctx context.Context, arg TouchSessionParams) error {
+	_, err := q.db.ExecContext(ctx, touchSession, arg.ID, arg.ExpiresAt)
+	return err
+}
+
+const updateTOTPLastUsedStep = `-- name: UpdateTOTPLastUsedStep :exec
+UPDATE account.user_totp
+SET last_used_step = $2
+WHERE user_id = $1
+`
+
+type UpdateTOTPLastUsedStepParams struct {
+	UserID       uuid.UUID `json:"user_id"`
+	LastUsedStep int64     `json:"last_used_step"`
+}
+
+func (q *Queries) UpdateTOTPLastUsedStep(ctx context.Context, arg UpdateTOTPLastUsedStepParams) error {
+	_, err := q.db.ExecContext(ctx, updateTOTPLastUsedStep, arg.UserID, arg.LastUsedStep)
+	return err
+}
+
+const updateUserIdentityLogin = `-- name: UpdateUserIdentityLogin :exec
+UPDATE account.user_identities
+SET email = $2, last_login_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdateUserIdentityLoginParams struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) UpdateUserIdentityLogin(ctx context.Context, arg UpdateUserIdentityLoginParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserIdentityLogin, arg.ID, arg.Email)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE account.users
+SET password_hash = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           uuid.UUID `json:"id"`
+	PasswordHash string    `json:"password_hash"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const updateWebAuthnCredentialUsage = `-- name: UpdateWebAuthnCredentialUsage :exec
+UPDATE account.webauthn_credentials
+SET sign_count = $2, backup_state = $3, last_used_at = CURRENT_TIMESTAMP
+WHERE credential_id = $1
+`
+
+type UpdateWebAuthnCredentialUsageParams struct {
+	CredentialID []byte `json:"credential_id"`
+	SignCount    int64  `json:"sign_count"`
+	BackupState  bool   `json:"backup_state"`
+}
+
+func (q *Queries) UpdateWebAuthnCredentialUsage(ctx context.Context, arg UpdateWebAuthnCredentialUsageParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebAuthnCredentialUsage, arg.CredentialID, arg.SignCount, arg.BackupState)
+	return err
+}
+
+const upsertProviderToken = `-- name: UpsertProviderToken :exec
+INSERT INTO account.provider_tokens (identity_id, access_token, refresh_token, expires_at, refresh_expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (identity_id) DO UPDATE
+SET access_token = EXCLUDED.access_token,
+    refresh_token = COALESCE(EXCLUDED.refresh_token, account.provider_tokens.refresh_token),
+    expires_at = EXCLUDED.expires_at,
+    refresh_expires_at = CASE
+        WHEN EXCLUDED.refresh_token IS NULL THEN account.provider_tokens.refresh_expires_at
+        ELSE EXCLUDED.refresh_expires_at
+    END,
+    updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertProviderTokenParams struct {
+	IdentityID       uuid.UUID    `json:"identity_id"`
+	AccessToken      []byte       `json:"access_token"`
+	RefreshToken     []byte       `json:"refresh_token"`
+	ExpiresAt        sql.NullTime `json:"expires_at"`
+	RefreshExpiresAt sql.NullTime `json:"refresh_expires_at"`
+}
+
+func (q *Queries) UpsertProviderToken(ctx context.Context, arg UpsertProviderTokenParams) error {
+	_, err := q.db.ExecContext(ctx, upsertProviderToken,
+		arg.IdentityID,
+		arg.AccessToken,
+		arg.RefreshToken,
+		arg.ExpiresAt,
+		arg.RefreshExpiresAt,
+	)
+	return err
+}
+
+const upsertUserTOTP = `-- name: UpsertUserTOTP :exec
+INSERT INTO account.user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, confirmed_at = NULL, created_at = CURRENT_TIMESTAMP
+`
+
+type UpsertUserTOTPParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Secret []byte    `json:"secret"`
+}
+
+func (q *Queries) UpsertUserTOTP(ctx context.Context, arg UpsertUserTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, upsertUserTOTP, arg.UserID, arg.Secret)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE account.recovery_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	CodeHash string    `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -1,13 +1,295 @@
+-- name: ActivateSigningKey :execrows
+UPDATE account.signing_keys
+SET activated_at = CURRENT_TIMESTAMP, retired_at = NULL
+WHERE kid = $1;
+
+-- name: ClaimOutboxMessages :many
+SELECT id, kind, recipient, locale, payload, attempts FROM account.outbox
+WHERE sent_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP AND attempts < sqlc.arg(max_attempts)::int
+ORDER BY next_attempt_at
+LIMIT sqlc.arg(batch_size)::int
+FOR UPDATE SKIP LOCKED;
+
+-- name: ClaimUserIdentity :exec
+UPDATE account.user_identities
+SET provider_user_id = $2
+WHERE id = $1;
+
+-- name: ConfirmUserTOTP :exec
+UPDATE account.user_totp
+SET confirmed_at = CURRENT_TIMESTAMP, last_used_step = $2
+WHERE user_id = $1;
+
+-- name: ConsumeRefreshToken :exec
+UPDATE account.refresh_tokens
+SET consumed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: DeleteFinishedOutboxMessages :execrows
+DELETE FROM account.outbox
+WHERE created_at < sqlc.arg(before)::timestamp
+  AND (sent_at IS NOT NULL OR attempts >= sqlc.arg(max_attempts)::int);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM account.recovery_codes
+WHERE user_id = $1;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM account.user_identities
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM account.user_totp
+WHERE user_id = $1;
+
+-- name: GetEmailVerificationTokenForUpdate :one
+SELECT id, user_id, expires_at, used_at FROM account.email_verification_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: GetPasswordResetTokenForUpdate :one
+SELECT id, user_id, expires_at, used_at FROM account.password_reset_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: GetProviderTokenForUpdate :one
+SELECT t.identity_id, t.access_token, t.refresh_token, t.expires_at, t.refresh_expires_at, t.updated_at
+FROM account.provider_tokens t
+JOIN account.user_identities i ON i.id = t.identity_id
+WHERE i.user_id = $1 AND i.provider = $2
+FOR UPDATE OF t;
+
+-- name: GetRefreshTokenForUpdate :one
+SELECT id, user_id, family_id, session_id, expires_at, consumed_at, revoked_at
+FROM account.refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: GetRole :one
+SELECT name, description, created_at FROM account.roles
+WHERE name = $1;
+
+-- name: GetSession :one
+SELECT id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+FROM account.sessions
+WHERE id = $1;
+
 -- name: GetUserByEmail :one
-SELECT id, email, password_hash
+SELECT id, email, password_hash, email_verified_at
 FROM account.users
-WHERE email = $1;
+WHERE email = $1 AND email <> '';
+
+-- name: GetUserByID :one
+SELECT id, email, password_hash, email_verified_at FROM account.users
+WHERE id = $1;
+
+-- name: GetUserByIDForUpdate :one
+SELECT id, email, password_hash, email_verified_at FROM account.users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetUserIdentity :one
+SELECT id, user_id, provider, provider_user_id, email, created_at, last_login_at
+FROM account.user_identities
+WHERE provider = $1 AND provider_user_id = $2;
+
+-- name: GetUserTOTP :one
+SELECT user_id, secret, last_used_step, confirmed_at, created_at FROM account.user_totp
+WHERE user_id = $1;
+
+-- name: GetUserTOTPForUpdate :one
+SELECT user_id, secret, last_used_step, confirmed_at, created_at FROM account.user_totp
+WHERE user_id = $1
+FOR UPDATE;
+
+-- name: GrantUserRole :exec
+INSERT INTO account.user_roles (user_id, role)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: InsertEmailVerificationToken :exec
+INSERT INTO account.email_verification_tokens (id, user_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: InsertOutboxMessage :exec
+INSERT INTO account.outbox (id, kind, recipient, locale, payload)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: InsertPasswordResetToken :exec
+INSERT INTO account.password_reset_tokens (id, user_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: InsertRecoveryCode :exec
+INSERT INTO account.recovery_codes (id, user_id, code_hash)
+VALUES ($1, $2, $3);
 
 -- name: InsertRefreshToken :exec
-INSERT INTO account.refresh_tokens (id, user_id, token, expires_at)
+INSERT INTO account.refresh_tokens (id, user_id, family_id, session_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: InsertSession :exec
+INSERT INTO account.sessions (id, user_id, device_name, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: InsertSigningKey :exec
+INSERT INTO account.signing_keys (kid, algorithm, private_key, created_at)
 VALUES ($1, $2, $3, $4);
 
 -- name: InsertUser :one
 INSERT INTO account.users (id, email, password_hash)
 VALUES ($1, $2, $3)
-RETURNING id;
\ No newline at end of file
+RETURNING id;
+
+-- name: InsertUserIdentity :exec
+INSERT INTO account.user_identities (id, user_id, provider, provider_user_id, email)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: InsertWebAuthnCredential :exec
+INSERT INTO account.webauthn_credentials (
+    id, user_id, credential_id, public_key, attestation_type, aaguid,
+    sign_count, transports, backup_eligible, backup_state, name
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: InvalidateEmailVerificationTokens :exec
+UPDATE account.email_verification_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: InvalidatePasswordResetTokens :exec
+UPDATE account.password_reset_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: ListActiveSessionsByUser :many
+SELECT id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+FROM account.sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+ORDER BY last_seen_at DESC;
+
+-- name: ListSigningKeys :many
+SELECT kid, algorithm, private_key, created_at, activated_at, retired_at
+FROM account.signing_keys
+ORDER BY created_at;
+
+-- name: ListUserIdentitiesByUser :many
+SELECT id, user_id, provider, provider_user_id, email, created_at, last_login_at
+FROM account.user_identities
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ListUserRoles :many
+SELECT role FROM account.user_roles
+WHERE user_id = $1
+ORDER BY role;
+
+-- name: ListWebAuthnCredentialsByUser :many
+SELECT id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count, transports, backup_eligible, backup_state, name, created_at, last_used_at
+FROM account.webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: MarkEmailVerified :exec
+UPDATE account.users
+SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND email_verified_at IS NULL;
+
+-- name: MarkOutboxMessageFailed :exec
+UPDATE account.outbox
+SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+WHERE id = $1;
+
+-- name: MarkOutboxMessageSent :exec
+UPDATE account.outbox
+SET attempts = attempts + 1, sent_at = CURRENT_TIMESTAMP, last_error = NULL, payload = '{}'
+WHERE id = $1;
+
+-- name: RetireActiveSigningKeys :exec
+UPDATE account.signing_keys
+SET retired_at = CURRENT_TIMESTAMP
+WHERE kid <> $1 AND activated_at IS NOT NULL AND retired_at IS NULL;
+
+-- name: RevokeRefreshToken :exec
+UPDATE account.refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE token_hash = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE account.refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeSession :execrows
+UPDATE account.sessions
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeSessionRefreshTokens :exec
+UPDATE account.refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE session_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE account.refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND revoked_at IS NULL
+  AND (session_id IS NULL OR session_id <> sqlc.arg(except_session_id)::uuid);
+
+-- name: RevokeUserRole :execrows
+DELETE FROM account.user_roles
+WHERE user_id = $1 AND role = $2;
+
+-- name: RevokeUserSessions :many
+UPDATE account.sessions
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND id <> sqlc.arg(except_session_id)::uuid AND revoked_at IS NULL
+RETURNING id;
+
+-- name: TouchSession :exec
+UPDATE account.sessions
+SET last_seen_at = CURRENT_TIMESTAMP, expires_at = $2
+WHERE id = $1;
+
+-- name: UpdateTOTPLastUsedStep :exec
+UPDATE account.user_totp
+SET last_used_step = $2
+WHERE user_id = $1;
+
+-- name: UpdateUserIdentityLogin :exec
+UPDATE account.user_identities
+SET email = $2, last_login_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE account.users
+SET password_hash = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateWebAuthnCredentialUsage :exec
+UPDATE account.webauthn_credentials
+SET sign_count = $2, backup_state = $3, last_used_at = CURRENT_TIMESTAMP
+WHERE credential_id = $1;
+
+-- name: UpsertProviderToken :exec
+INSERT INTO account.provider_tokens (identity_id, access_token, refresh_token, expires_at, refresh_expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (identity_id) DO UPDATE
+SET access_token = EXCLUDED.access_token,
+    refresh_token = COALESCE(EXCLUDED.refresh_token, account.provider_tokens.refresh_token),
+    expires_at = EXCLUDED.expires_at,
+    refresh_expires_at = CASE
+        WHEN EXCLUDED.refresh_token IS NULL THEN account.provider_tokens.refresh_expires_at
+        ELSE EXCLUDED.refresh_expires_at
+    END,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: UpsertUserTOTP :exec
+INSERT INTO account.user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, confirmed_at = NULL, created_at = CURRENT_TIMESTAMP;
+
+-- name: UseRecoveryCode :execrows
+UPDATE account.recovery_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
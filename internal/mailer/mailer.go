@@ -0,0 +1,42 @@
+// Package mailer 메일 발송과 메일 템플릿
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/escape-ship/accountsrv/config"
+)
+
+// Message 발송할 메일. 본문은 text/plain (UTF-8) 이다.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 메일 발송 방식
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New 설정(mail.driver)에 맞는 Mailer 를 만든다.
+func New(cfg config.Mail, logger *slog.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		if cfg.SMTP.Host == "" {
+			return nil, fmt.Errorf("mailer: smtp.host must be set for smtp driver")
+		}
+		return NewSMTPMailer(cfg.SMTP, cfg.From), nil
+	case "file":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("mailer: dir must be set for file driver")
+		}
+		return NewFileMailer(cfg.Dir, cfg.From), nil
+	case "log", "":
+		return NewLogMailer(logger), nil
+	default:
+		return nil, fmt.Errorf("mailer: unknown driver %q", cfg.Driver)
+	}
+}
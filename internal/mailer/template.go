@@ -0,0 +1,97 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Kind 메일 종류. 템플릿 파일 이름과 같다 (templates/<locale>/<kind>.tmpl).
+type Kind string
+
+const (
+	KindEmailVerification Kind = "email_verification" // Data: token, expires_at
+	KindPasswordReset     Kind = "password_reset"     // Data: token, expires_at
+	KindPasswordChanged   Kind = "password_changed"   // Data: 없음
+	KindAccountExists     Kind = "account_exists"     // Data: 없음. 이미 가입된 이메일로 가입을 시도했을 때
+)
+
+// 지원하는 언어
+const (
+	LocaleKorean  = "ko"
+	LocaleEnglish = "en"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// TemplateData 템플릿에서 쓰는 값
+type TemplateData struct {
+	To      string
+	BaseURL string
+	Data    map[string]string
+}
+
+// Renderer Kind 와 언어에 맞는 템플릿으로 메일을 만든다.
+// 템플릿은 "subject" 와 "body" 를 정의한다.
+type Renderer struct {
+	baseURL       string
+	defaultLocale string
+	templates     map[string]*template.Template // "<locale>/<kind>"
+}
+
+func NewRenderer(baseURL, defaultLocale string) (*Renderer, error) {
+	r := &Renderer{
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		defaultLocale: NormalizeLocale(defaultLocale, LocaleKorean),
+		templates:     make(map[string]*template.Template),
+	}
+	for _, locale := range []string{LocaleKorean, LocaleEnglish} {
+		for _, kind := range []Kind{KindEmailVerification, KindPasswordReset, KindPasswordChanged, KindAccountExists} {
+			name := fmt.Sprintf("%s/%s", locale, kind)
+			t, err := template.ParseFS(templateFS, "templates/"+name+".tmpl")
+			if err != nil {
+				return nil, fmt.Errorf("mailer: parse template %s: %w", name, err)
+			}
+			r.templates[name] = t
+		}
+	}
+	return r, nil
+}
+
+// Render 메일 제목과 본문을 만든다. 지원하지 않는 언어는 기본 언어로 보낸다.
+func (r *Renderer) Render(kind Kind, locale, to string, data map[string]string) (Message, error) {
+	t, ok := r.templates[fmt.Sprintf("%s/%s", NormalizeLocale(locale, r.defaultLocale), kind)]
+	if !ok {
+		return Message{}, fmt.Errorf("mailer: no template for %s", kind)
+	}
+	td := TemplateData{To: to, BaseURL: r.baseURL, Data: data}
+
+	var subject, body bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", td); err != nil {
+		return Message{}, err
+	}
+	if err := t.ExecuteTemplate(&body, "body", td); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimLeft(body.String(), "\n"),
+	}, nil
+}
+
+// NormalizeLocale Accept-Language 등의 값에서 지원하는 언어를 고른다. 없으면 fallback.
+func NormalizeLocale(locale, fallback string) string {
+	for _, part := range strings.Split(locale, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		switch lang {
+		case LocaleKorean, LocaleEnglish:
+			return lang
+		}
+	}
+	return fallback
+}
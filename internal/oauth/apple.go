@@ -0,0 +1,109 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/escape-ship/accountsrv/config"
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	appleIssuer   = "https://appleid.apple.com"
+	appleAuthURL  = "https://appleid.apple.com/auth/authorize"
+	appleTokenURL = "https://appleid.apple.com/auth/token"
+	appleJWKSURL  = "https://appleid.apple.com/auth/keys"
+
+	// appleClientSecretTTL 토큰 요청마다 새로 서명하므로 짧게 둔다. (Apple 상한 6개월)
+	appleClientSecretTTL = 5 * time.Minute
+)
+
+// Apple Sign in with Apple (OpenID Connect).
+// client_secret 은 Apple 에서 받은 .p8 키로 서명한 ES256 JWT 이다.
+// 이름은 최초 동의 때 form_post 본문으로만 오므로 프로필에 담지 않는다.
+type Apple struct {
+	cfg      config.OAuthProvider
+	key      *auth.SigningKey
+	verifier *idTokenVerifier
+}
+
+func NewApple(cfg config.OAuthProvider) (*Apple, error) {
+	if cfg.TeamID == "" || cfg.KeyID == "" || cfg.PrivateKeyFile == "" {
+		return nil, fmt.Errorf("oauth: apple.team_id, apple.key_id and apple.private_key_file must be set")
+	}
+	key, err := auth.LoadSigningKeyFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("oauth: apple: %w", err)
+	}
+	if key.Method != jwt.SigningMethodES256 {
+		return nil, fmt.Errorf("oauth: apple: private key must be a P-256 EC key, got %s", key.Method.Alg())
+	}
+	return &Apple{
+		cfg:      cfg,
+		key:      key,
+		verifier: newIDTokenVerifier(appleJWKSURL, cfg.ClientID, appleIssuer),
+	}, nil
+}
+
+func (*Apple) Name() string { return ProviderApple }
+
+func (a *Apple) AuthCodeURL(req AuthRequest) string {
+	query := url.Values{}
+	query.Set("client_id", a.cfg.ClientID)
+	query.Set("redirect_uri", a.cfg.RedirectURI)
+	query.Set("response_type", "code")
+	query.Set("state", req.State)
+	query.Set("nonce", req.Nonce)
+	if s := scopes(a.cfg.Scopes, nil); len(s) > 0 {
+		// scope 를 요청하면 Apple 은 form_post 로만 콜백한다.
+		query.Set("scope", strings.Join(s, " "))
+		query.Set("response_mode", "form_post")
+	}
+	return appleAuthURL + "?" + query.Encode()
+}
+
+func (a *Apple) Exchange(ctx context.Context, code string, _ AuthRequest) (*Token, error) {
+	secret, err := a.clientSecret()
+	if err != nil {
+		return nil, fmt.Errorf("oauth: apple client secret: %w", err)
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", a.cfg.ClientID)
+	form.Set("client_secret", secret)
+	form.Set("redirect_uri", a.cfg.RedirectURI)
+	form.Set("code", code)
+	return requestToken(ctx, ProviderApple, appleTokenURL, form)
+}
+
+func (a *Apple) Profile(ctx context.Context, token *Token, req AuthRequest) (*Profile, error) {
+	claims, err := a.verifier.verify(ctx, token.IDToken, req.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("oauth: apple: %w", err)
+	}
+	return &Profile{
+		Provider:      ProviderApple,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+	}, nil
+}
+
+// clientSecret 토큰 요청에 쓰는 client_secret JWT
+func (a *Apple) clientSecret() (string, error) {
+	now := time.Now()
+	// aud 는 배열이 아닌 문자열이어야 하므로 MapClaims 를 쓴다.
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": a.cfg.TeamID,
+		"sub": a.cfg.ClientID,
+		"aud": appleIssuer,
+		"iat": now.Unix(),
+		"exp": now.Add(appleClientSecretTTL).Unix(),
+	})
+	t.Header["kid"] = a.cfg.KeyID
+	return t.SignedString(a.key.Private)
+}
@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/escape-ship/accountsrv/config"
+)
+
+const (
+	googleAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	googleJWKSURL  = "https://www.googleapis.com/oauth2/v3/certs"
+)
+
+// Google 구글 로그인 (OpenID Connect). 사용자 정보는 검증한 ID 토큰에서 읽는다.
+type Google struct {
+	cfg      config.OAuthProvider
+	verifier *idTokenVerifier
+}
+
+func NewGoogle(cfg config.OAuthProvider) *Google {
+	return &Google{
+		cfg:      cfg,
+		verifier: newIDTokenVerifier(googleJWKSURL, cfg.ClientID, "https://accounts.google.com", "accounts.google.com"),
+	}
+}
+
+func (*Google) Name() string { return ProviderGoogle }
+
+func (g *Google) AuthCodeURL(req AuthRequest) string {
+	query := url.Values{}
+	query.Set("client_id", g.cfg.ClientID)
+	query.Set("redirect_uri", g.cfg.RedirectURI)
+	query.Set("response_type", "code")
+	query.Set("scope", strings.Join(scopes(g.cfg.Scopes, []string{"openid", "email", "profile"}), " "))
+	query.Set("state", req.State)
+	query.Set("nonce", req.Nonce)
+	query.Set("code_challenge", pkceChallenge(req.CodeVerifier))
+	query.Set("code_challenge_method", "S256")
+	return googleAuthURL + "?" + query.Encode()
+}
+
+func (g *Google) Exchange(ctx context.Context, code string, req AuthRequest) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", g.cfg.ClientID)
+	form.Set("client_secret", g.cfg.ClientSecret)
+	form.Set("redirect_uri", g.cfg.RedirectURI)
+	form.Set("code", code)
+	form.Set("code_verifier", req.CodeVerifier)
+	return requestToken(ctx, ProviderGoogle, googleTokenURL, form)
+}
+
+func (g *Google) Profile(ctx context.Context, token *Token, req AuthRequest) (*Profile, error) {
+	claims, err := g.verifier.verify(ctx, token.IDToken, req.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("oauth: google: %w", err)
+	}
+	return &Profile{
+		Provider:      ProviderGoogle,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
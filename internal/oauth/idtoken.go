@@ -0,0 +1,153 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	jwksMaxAge     = time.Hour   // 이 시간이 지나면 JWKS 를 다시 받는다
+	jwksMinRefresh = time.Minute // 모르는 kid 로 다시 받는 최소 간격
+)
+
+// idTokenClaims OIDC ID 토큰 클레임
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+// flexBool true/false 와 "true"/"false" 를 모두 받는다. (Apple 은 문자열로 준다)
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*b = flexBool(v)
+		return nil
+	}
+	var v bool
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*b = flexBool(v)
+	return nil
+}
+
+// idTokenVerifier 제공자 JWKS 로 ID 토큰의 서명, iss, aud, exp, nonce 를 검증한다.
+// 키는 캐시하고, 제공자가 키를 교체해 모르는 kid 가 오면 다시 받는다.
+type idTokenVerifier struct {
+	jwksURL  string
+	issuers  []string
+	clientID string
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func newIDTokenVerifier(jwksURL, clientID string, issuers ...string) *idTokenVerifier {
+	return &idTokenVerifier{
+		jwksURL:  jwksURL,
+		issuers:  issuers,
+		clientID: clientID,
+	}
+}
+
+func (v *idTokenVerifier) verify(ctx context.Context, raw, nonce string) (*idTokenClaims, error) {
+	if raw == "" {
+		return nil, errors.New("no id_token in token response")
+	}
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithAudience(v.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if !slices.Contains(v.issuers, claims.Issuer) {
+		return nil, fmt.Errorf("invalid id_token: unexpected issuer %q", claims.Issuer)
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id_token: no subject")
+	}
+	if nonce != "" && subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+	return &claims, nil
+}
+
+// key kid 에 해당하는 공개키
+func (v *idTokenVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > jwksMaxAge
+	if ok && !stale {
+		return key, nil
+	}
+	if stale || time.Since(v.fetchedAt) > jwksMinRefresh {
+		if err := v.fetch(ctx); err != nil {
+			if ok {
+				// 캐시된 키가 있으면 제공자 장애 중에도 계속 쓴다.
+				return key, nil
+			}
+			return nil, err
+		}
+		if key, ok := v.keys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (v *idTokenVerifier) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return err
+	}
+	var set auth.JWKS
+	if err := doJSON(req, &set); err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue // 지원하지 않는 키 종류는 건너뛴다
+		}
+		keys[jwk.Kid] = key
+	}
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	return nil
+}
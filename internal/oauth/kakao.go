@@ -0,0 +1,109 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/escape-ship/accountsrv/config"
+)
+
+const (
+	kakaoAuthURL  = "https://kauth.kakao.com/oauth/authorize"
+	kakaoTokenURL = "https://kauth.kakao.com/oauth/token"
+	kakaoUserURL  = "https://kapi.kakao.com/v2/user/me"
+)
+
+// Kakao 카카오 로그인 (OAuth 2.0)
+type Kakao struct {
+	cfg config.OAuthProvider
+}
+
+func NewKakao(cfg config.OAuthProvider) *Kakao {
+	return &Kakao{cfg: cfg}
+}
+
+// kakaoUserInfo /v2/user/me 응답
+type kakaoUserInfo struct {
+	ID         int64 `json:"id"`
+	Properties struct {
+		Nickname string `json:"nickname"`
+	} `json:"properties"`
+	KakaoAccount struct {
+		Email           string `json:"email"`
+		HasEmail        bool   `json:"has_email"`
+		IsEmailValid    bool   `json:"is_email_valid"`
+		IsEmailVerified bool   `json:"is_email_verified"`
+		Profile         struct {
+			Nickname string `json:"nickname"`
+		} `json:"profile"`
+	} `json:"kakao_account"`
+}
+
+func (*Kakao) Name() string { return ProviderKakao }
+
+func (k *Kakao) AuthCodeURL(req AuthRequest) string {
+	query := url.Values{}
+	query.Set("client_id", k.cfg.ClientID)
+	query.Set("redirect_uri", k.cfg.RedirectURI)
+	query.Set("response_type", "code")
+	query.Set("state", req.State)
+	query.Set("code_challenge", pkceChallenge(req.CodeVerifier))
+	query.Set("code_challenge_method", "S256")
+	if len(k.cfg.Scopes) > 0 {
+		query.Set("scope", strings.Join(k.cfg.Scopes, ","))
+	}
+	return kakaoAuthURL + "?" + query.Encode()
+}
+
+func (k *Kakao) Exchange(ctx context.Context, code string, req AuthRequest) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", k.cfg.ClientID)
+	form.Set("redirect_uri", k.cfg.RedirectURI)
+	form.Set("code", code)
+	form.Set("code_verifier", req.CodeVerifier)
+	if k.cfg.ClientSecret != "" {
+		form.Set("client_secret", k.cfg.ClientSecret)
+	}
+	return requestToken(ctx, ProviderKakao, kakaoTokenURL, form)
+}
+
+// Refresh 리프레시 토큰으로 액세스 토큰을 다시 받는다.
+// 리프레시 토큰은 만료가 가까울 때만 새로 발급되므로 응답에 없으면 기존 것을 계속 쓴다.
+func (k *Kakao) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("client_id", k.cfg.ClientID)
+	form.Set("refresh_token", refreshToken)
+	if k.cfg.ClientSecret != "" {
+		form.Set("client_secret", k.cfg.ClientSecret)
+	}
+	return requestToken(ctx, ProviderKakao, kakaoTokenURL, form)
+}
+
+func (k *Kakao) Profile(ctx context.Context, token *Token, _ AuthRequest) (*Profile, error) {
+	query := url.Values{}
+	query.Set("property_keys", `["kakao_account.email","kakao_account.profile"]`)
+
+	var info kakaoUserInfo
+	if err := getJSON(ctx, kakaoUserURL+"?"+query.Encode(), token.AccessToken, &info); err != nil {
+		return nil, fmt.Errorf("oauth: kakao user info: %w", err)
+	}
+	if info.ID == 0 {
+		return nil, fmt.Errorf("oauth: kakao user info: no user id in response")
+	}
+	name := info.KakaoAccount.Profile.Nickname
+	if name == "" {
+		name = info.Properties.Nickname
+	}
+	return &Profile{
+		Provider:      ProviderKakao,
+		Subject:       strconv.FormatInt(info.ID, 10),
+		Email:         info.KakaoAccount.Email,
+		EmailVerified: info.KakaoAccount.IsEmailValid && info.KakaoAccount.IsEmailVerified,
+		Name:          name,
+	}, nil
+}
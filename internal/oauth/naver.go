@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/escape-ship/accountsrv/config"
+)
+
+const (
+	naverAuthURL  = "https://nid.naver.com/oauth2.0/authorize"
+	naverTokenURL = "https://nid.naver.com/oauth2.0/token"
+	naverUserURL  = "https://openapi.naver.com/v1/nid/me"
+)
+
+// Naver 네이버 로그인 (OAuth 2.0). PKCE 를 지원하지 않아 state 로만 요청을 묶는다.
+type Naver struct {
+	cfg config.OAuthProvider
+}
+
+func NewNaver(cfg config.OAuthProvider) *Naver {
+	return &Naver{cfg: cfg}
+}
+
+// naverUserInfo /v1/nid/me 응답
+type naverUserInfo struct {
+	ResultCode string `json:"resultcode"`
+	Message    string `json:"message"`
+	Response   struct {
+		ID       string `json:"id"`
+		Email    string `json:"email"`
+		Name     string `json:"name"`
+		Nickname string `json:"nickname"`
+	} `json:"response"`
+}
+
+func (*Naver) Name() string { return ProviderNaver }
+
+func (n *Naver) AuthCodeURL(req AuthRequest) string {
+	query := url.Values{}
+	query.Set("client_id", n.cfg.ClientID)
+	query.Set("redirect_uri", n.cfg.RedirectURI)
+	query.Set("response_type", "code")
+	query.Set("state", req.State)
+	return naverAuthURL + "?" + query.Encode()
+}
+
+func (n *Naver) Exchange(ctx context.Context, code string, req AuthRequest) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", n.cfg.ClientID)
+	form.Set("client_secret", n.cfg.ClientSecret)
+	form.Set("code", code)
+	form.Set("state", req.State)
+	return requestToken(ctx, ProviderNaver, naverTokenURL, form)
+}
+
+func (n *Naver) Profile(ctx context.Context, token *Token, _ AuthRequest) (*Profile, error) {
+	var info naverUserInfo
+	if err := getJSON(ctx, naverUserURL, token.AccessToken, &info); err != nil {
+		return nil, fmt.Errorf("oauth: naver user info: %w", err)
+	}
+	if info.ResultCode != "00" {
+		return nil, fmt.Errorf("oauth: naver user info: %s: %s", info.ResultCode, info.Message)
+	}
+	if info.Response.ID == "" {
+		return nil, fmt.Errorf("oauth: naver user info: no user id in response")
+	}
+	name := info.Response.Name
+	if name == "" {
+		name = info.Response.Nickname
+	}
+	// 네이버는 이메일 인증 여부를 알려주지 않으므로 인증되지 않은 것으로 본다.
+	return &Profile{
+		Provider: ProviderNaver,
+		Subject:  info.Response.ID,
+		Email:    info.Response.Email,
+		Name:     name,
+	}, nil
+}
@@ -0,0 +1,246 @@
+// Package oauth 소셜 로그인 제공자 (OAuth 2.0 / OpenID Connect)
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/escape-ship/accountsrv/config"
+)
+
+// 제공자 이름. GetSocialLoginURL/SocialCallback 의 provider 값과 같다.
+const (
+	ProviderKakao  = "kakao"
+	ProviderNaver  = "naver"
+	ProviderGoogle = "google"
+	ProviderApple  = "apple"
+)
+
+// AuthRequest 인가 요청 하나의 일회용 값. 로그인 URL 을 만들 때 생성해 콜백까지 보관한다.
+type AuthRequest struct {
+	State        string
+	CodeVerifier string // PKCE (RFC 7636)
+	Nonce        string // OIDC ID 토큰 재사용 방지
+}
+
+// ErrInvalidGrant 인가 코드나 리프레시 토큰이 만료/폐기되어 거부된 경우.
+// 리프레시 토큰이면 사용자가 다시 로그인해야 한다.
+var ErrInvalidGrant = errors.New("oauth: invalid grant")
+
+// Token 제공자가 발급한 토큰
+type Token struct {
+	AccessToken      string
+	RefreshToken     string // 갱신 응답에는 없을 수 있다
+	IDToken          string
+	ExpiresAt        time.Time // 액세스 토큰 만료. 알 수 없으면 zero
+	RefreshExpiresAt time.Time // 리프레시 토큰 만료. 알 수 없으면 zero
+}
+
+// Profile 제공자 구분 없이 정규화한 사용자 정보
+type Profile struct {
+	Provider      string
+	Subject       string // 제공자 안에서 바뀌지 않는 사용자 ID
+	Email         string
+	EmailVerified bool // 제공자가 이메일 소유를 확인했는지
+	Name          string
+}
+
+// Provider 소셜 로그인 제공자
+type Provider interface {
+	Name() string
+	// AuthCodeURL 사용자를 보낼 인가 URL
+	AuthCodeURL(req AuthRequest) string
+	// Exchange 콜백으로 받은 인가 코드를 토큰으로 교환한다.
+	Exchange(ctx context.Context, code string, req AuthRequest) (*Token, error)
+	// Profile 토큰으로 사용자 정보를 가져온다. OIDC 제공자는 ID 토큰을 검증해 읽는다.
+	Profile(ctx context.Context, token *Token, req AuthRequest) (*Profile, error)
+}
+
+// Refresher 리프레시 토큰으로 액세스 토큰을 다시 받을 수 있는 제공자
+type Refresher interface {
+	Refresh(ctx context.Context, refreshToken string) (*Token, error)
+}
+
+// httpClient 제공자 API 호출에 쓰는 클라이언트
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// New 설정에서 client_id 가 있는 제공자만 만든다.
+func New(cfg config.OAuth) (map[string]Provider, error) {
+	providers := make(map[string]Provider)
+	if cfg.Kakao.ClientID != "" {
+		if err := requireRedirectURI(ProviderKakao, cfg.Kakao); err != nil {
+			return nil, err
+		}
+		providers[ProviderKakao] = NewKakao(cfg.Kakao)
+	}
+	if cfg.Naver.ClientID != "" {
+		if err := requireRedirectURI(ProviderNaver, cfg.Naver); err != nil {
+			return nil, err
+		}
+		if cfg.Naver.ClientSecret == "" {
+			return nil, fmt.Errorf("oauth: naver.client_secret must be set")
+		}
+		providers[ProviderNaver] = NewNaver(cfg.Naver)
+	}
+	if cfg.Google.ClientID != "" {
+		if err := requireRedirectURI(ProviderGoogle, cfg.Google); err != nil {
+			return nil, err
+		}
+		if cfg.Google.ClientSecret == "" {
+			return nil, fmt.Errorf("oauth: google.client_secret must be set")
+		}
+		providers[ProviderGoogle] = NewGoogle(cfg.Google)
+	}
+	if cfg.Apple.ClientID != "" {
+		if err := requireRedirectURI(ProviderApple, cfg.Apple); err != nil {
+			return nil, err
+		}
+		apple, err := NewApple(cfg.Apple)
+		if err != nil {
+			return nil, err
+		}
+		providers[ProviderApple] = apple
+	}
+	return providers, nil
+}
+
+func requireRedirectURI(name string, cfg config.OAuthProvider) error {
+	if cfg.RedirectURI == "" {
+		return fmt.Errorf("oauth: %s.redirect_uri must be set", name)
+	}
+	return nil
+}
+
+// pkceChallenge code_verifier 의 S256 code_challenge
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// tokenResponse 토큰 엔드포인트 응답 (RFC 6749 5.1)
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	// 카카오는 리프레시 토큰 만료도 알려준다.
+	RefreshTokenExpiresIn int64 `json:"refresh_token_expires_in"`
+	// 네이버는 실패해도 200 으로 응답한다.
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (r *tokenResponse) token() *Token {
+	token := &Token{
+		AccessToken:  r.AccessToken,
+		RefreshToken: r.RefreshToken,
+		IDToken:      r.IDToken,
+	}
+	now := time.Now()
+	if r.ExpiresIn > 0 {
+		token.ExpiresAt = now.Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	if r.RefreshTokenExpiresIn > 0 {
+		token.RefreshExpiresAt = now.Add(time.Duration(r.RefreshTokenExpiresIn) * time.Second)
+	}
+	return token
+}
+
+// requestToken 토큰 엔드포인트에 form 을 보낸다.
+func requestToken(ctx context.Context, name, endpoint string, form url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	statusCode, body, err := do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth: %s token request: %w", name, err)
+	}
+	// 실패 응답도 RFC 6749 5.2 형식의 본문을 준다.
+	var res tokenResponse
+	if err := json.Unmarshal(body, &res); err != nil && statusCode/100 == 2 {
+		return nil, fmt.Errorf("oauth: %s token request: decode response: %w", name, err)
+	}
+	if res.Error == "invalid_grant" {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidGrant, name, res.ErrorDescription)
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("oauth: %s token request: %s: %s", name, res.Error, res.ErrorDescription)
+	}
+	if statusCode/100 != 2 {
+		return nil, fmt.Errorf("oauth: %s token request: unexpected status %d: %s", name, statusCode, truncate(body, 512))
+	}
+	if res.AccessToken == "" {
+		return nil, fmt.Errorf("oauth: %s token request: no access_token in response", name)
+	}
+	return res.token(), nil
+}
+
+// getJSON 액세스 토큰으로 사용자 정보 API 를 호출한다.
+func getJSON(ctx context.Context, endpoint, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doJSON(req, v)
+}
+
+// doJSON 요청을 보내고 2xx 응답 본문을 v 로 디코딩한다.
+func doJSON(req *http.Request, v any) error {
+	statusCode, body, err := do(req)
+	if err != nil {
+		return err
+	}
+	if statusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %d: %s", statusCode, truncate(body, 512))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// do 요청을 보내고 상태 코드와 본문을 반환한다.
+func do(req *http.Request) (int, []byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, nil, fmt.Errorf("read response body: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+func truncate(body []byte, n int) string {
+	if len(body) > n {
+		return string(body[:n]) + "..."
+	}
+	return string(body)
+}
+
+// scopes 설정값이 없으면 기본값
+func scopes(configured, fallback []string) []string {
+	if len(configured) > 0 {
+		return configured
+	}
+	return fallback
+}
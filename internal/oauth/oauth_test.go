@@ -0,0 +1,50 @@
+package oauth
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/escape-ship/accountsrv/config"
+)
+
+func TestPKCEChallenge(t *testing.T) {
+	tests := []struct {
+		verifier  string
+		challenge string
+	}{
+		// RFC 7636 Appendix B
+		{"dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+		{"", "47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU"},
+	}
+	for _, tt := range tests {
+		if got := pkceChallenge(tt.verifier); got != tt.challenge {
+			t.Errorf("pkceChallenge(%q) = %q, want %q", tt.verifier, got, tt.challenge)
+		}
+	}
+}
+
+func TestAuthCodeURLSendsS256Challenge(t *testing.T) {
+	cfg := config.OAuthProvider{ClientID: "client", ClientSecret: "secret", RedirectURI: "https://example.com/callback"}
+	req := AuthRequest{State: "state", CodeVerifier: "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", Nonce: "nonce"}
+	for _, p := range []Provider{NewKakao(cfg), NewGoogle(cfg)} {
+		t.Run(p.Name(), func(t *testing.T) {
+			u, err := url.Parse(p.AuthCodeURL(req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := u.Query()
+			if got := query.Get("code_challenge"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+				t.Errorf("code_challenge = %q", got)
+			}
+			if got := query.Get("code_challenge_method"); got != "S256" {
+				t.Errorf("code_challenge_method = %q, want S256", got)
+			}
+			if got := query.Get("state"); got != req.State {
+				t.Errorf("state = %q, want %q", got, req.State)
+			}
+			if query.Has("code_verifier") {
+				t.Error("code_verifier leaked into the authorization URL")
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package outbox 트랜잭션 아웃박스. 메일을 DB 트랜잭션 안에서 쌓아 두고 Worker 가 발송한다.
+// 트랜잭션이 롤백되면 메일도 함께 사라지므로 실제로 일어나지 않은 변경에 대한 메일은 나가지 않는다.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	"github.com/google/uuid"
+)
+
+// Mail 발송 대기 메일
+type Mail struct {
+	Kind   mailer.Kind
+	To     string
+	Locale string
+	Data   map[string]string // 템플릿 데이터
+}
+
+// Enqueue 메일을 아웃박스에 쌓는다. qtx 는 메일을 발생시킨 변경과 같은 트랜잭션이어야 한다.
+// Data 는 발송될 때까지만 남는다 (Worker 참고).
+func Enqueue(ctx context.Context, qtx *postgresql.Queries, mail Mail) error {
+	payload, err := json.Marshal(mail.Data)
+	if err != nil {
+		return err
+	}
+	return qtx.InsertOutboxMessage(ctx, postgresql.InsertOutboxMessageParams{
+		ID:        uuid.New(),
+		Kind:      string(mail.Kind),
+		Recipient: mail.To,
+		Locale:    mail.Locale,
+		Payload:   payload,
+	})
+}
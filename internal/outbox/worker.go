@@ -0,0 +1,157 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/escape-ship/accountsrv/config"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+)
+
+const (
+	batchSize      = 20
+	minRetryDelay  = 30 * time.Second
+	maxRetryDelay  = time.Hour
+	maxErrorLength = 1000
+	purgeInterval  = time.Hour
+)
+
+// Worker 아웃박스의 메일을 주기적으로 발송한다. 실패한 메일은 지수 백오프로 재시도하고
+// max_attempts 를 넘기면 더 이상 시도하지 않는다 (last_error 에 마지막 오류가 남는다).
+// 여러 인스턴스가 동시에 돌아도 FOR UPDATE SKIP LOCKED 로 같은 메일을 두 번 집지 않는다.
+// 보낸 메일은 템플릿 데이터(토큰 원문)를 바로 비우고, 보냈거나 포기한 메일은 retention 이 지나면 지운다.
+type Worker struct {
+	db          *sql.DB
+	mailer      mailer.Mailer
+	renderer    *mailer.Renderer
+	interval    time.Duration
+	maxAttempts int
+	retention   time.Duration
+	logger      *slog.Logger
+}
+
+func NewWorker(db *sql.DB, m mailer.Mailer, r *mailer.Renderer, cfg config.Mail) *Worker {
+	return &Worker{
+		db:          db,
+		mailer:      m,
+		renderer:    r,
+		interval:    cfg.PollInterval,
+		maxAttempts: cfg.MaxAttempts,
+		retention:   cfg.Retention,
+		logger:      slog.Default().With("component", "outbox"),
+	}
+}
+
+// Run ctx 가 끝날 때까지 아웃박스를 처리한다.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	var lastPurge time.Time
+	for {
+		if w.retention > 0 && time.Since(lastPurge) >= purgeInterval {
+			lastPurge = time.Now()
+			if n, err := w.purge(ctx); err != nil {
+				w.logger.Error("Failed to purge outbox", slog.String("error", err.Error()))
+			} else if n > 0 {
+				w.logger.Info("Purged finished outbox messages", slog.Int64("count", n))
+			}
+		}
+		// 한 번에 batchSize 만큼 처리하므로 가득 찼으면 바로 다음 배치를 처리한다.
+		n, err := w.processBatch(ctx)
+		if err != nil {
+			w.logger.Error("Failed to process outbox", slog.String("error", err.Error()))
+		}
+		if n == batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processBatch 발송할 메일을 집어 보내고 결과를 기록한다. 처리한 메일 수를 반환한다.
+func (w *Worker) processBatch(ctx context.Context) (n int, err error) {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	qtx := postgresql.New(tx)
+
+	messages, err := qtx.ClaimOutboxMessages(ctx, postgresql.ClaimOutboxMessagesParams{
+		MaxAttempts: int32(w.maxAttempts),
+		BatchSize:   batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, msg := range messages {
+		logger := w.logger.With("outbox_id", msg.ID.String(), "kind", msg.Kind)
+		if sendErr := w.send(ctx, msg); sendErr != nil {
+			logger.Warn("Failed to send mail",
+				slog.Int("attempt", int(msg.Attempts)+1),
+				slog.String("error", sendErr.Error()))
+			errText := sendErr.Error()
+			if len(errText) > maxErrorLength {
+				errText = errText[:maxErrorLength]
+			}
+			if err = qtx.MarkOutboxMessageFailed(ctx, postgresql.MarkOutboxMessageFailedParams{
+				ID:            msg.ID,
+				LastError:     sql.NullString{String: errText, Valid: true},
+				NextAttemptAt: time.Now().Add(retryDelay(int(msg.Attempts) + 1)),
+			}); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err = qtx.MarkOutboxMessageSent(ctx, msg.ID); err != nil {
+			return 0, err
+		}
+		logger.Info("Mail sent")
+	}
+	return len(messages), nil
+}
+
+// purge 보존 기간이 지난 메일 중 보냈거나 max_attempts 를 넘겨 포기한 메일을 지운다.
+func (w *Worker) purge(ctx context.Context) (int64, error) {
+	return postgresql.New(w.db).DeleteFinishedOutboxMessages(ctx, postgresql.DeleteFinishedOutboxMessagesParams{
+		Before:      time.Now().Add(-w.retention),
+		MaxAttempts: int32(w.maxAttempts),
+	})
+}
+
+func (w *Worker) send(ctx context.Context, msg postgresql.ClaimOutboxMessagesRow) error {
+	var data map[string]string
+	if err := json.Unmarshal(msg.Payload, &data); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	mail, err := w.renderer.Render(mailer.Kind(msg.Kind), msg.Locale, msg.Recipient, data)
+	if err != nil {
+		return err
+	}
+	return w.mailer.Send(ctx, mail)
+}
+
+// retryDelay attempts 번 실패한 뒤 다음 시도까지의 대기 시간 (30s, 1m, 2m, ... 최대 1h)
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
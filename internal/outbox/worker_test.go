@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	"github.com/google/uuid"
+)
+
+// fakeOutbox sqlc 쿼리 이름으로 결과를 돌려주는 database/sql 드라이버. 실행된 쿼리와 인자를 기록한다.
+type fakeOutbox struct {
+	mu      sync.Mutex
+	pending [][]driver.Value
+	calls   map[string][][]driver.Value
+	queries map[string]string
+}
+
+var queryName = regexp.MustCompile(`-- name: (\w+)`)
+
+func (f *fakeOutbox) run(query string, named []driver.NamedValue) ([][]driver.Value, error) {
+	name := queryName.FindStringSubmatch(query)[1]
+	args := make([]driver.Value, len(named))
+	for i, v := range named {
+		args[i] = v.Value
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[name] = append(f.calls[name], args)
+	f.queries[name] = query
+	if name == "ClaimOutboxMessages" {
+		rows := f.pending
+		f.pending = nil
+		return rows, nil
+	}
+	return make([][]driver.Value, 1), nil
+}
+
+func (f *fakeOutbox) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeOutbox) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ f *fakeOutbox }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c fakeConn) Commit() error                       { return nil }
+func (c fakeConn) Rollback() error                     { return nil }
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.f.run(query, args)
+	return &fakeRows{rows: rows}, err
+}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := c.f.run(query, args)
+	return driver.RowsAffected(len(rows)), err
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string { return make([]string, 6) }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// fakeMailer failFor 로 가는 메일만 실패한다.
+type fakeMailer struct {
+	failFor string
+	sent    []mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	if msg.To == m.failFor {
+		return errors.New("550 mailbox unavailable")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func newTestWorker(t *testing.T, m mailer.Mailer) (*Worker, *fakeOutbox) {
+	t.Helper()
+	renderer, err := mailer.NewRenderer("http://localhost:3000", "ko")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeOutbox{calls: make(map[string][][]driver.Value), queries: make(map[string]string)}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return &Worker{
+		db:          db,
+		mailer:      m,
+		renderer:    renderer,
+		interval:    time.Second,
+		maxAttempts: 10,
+		retention:   168 * time.Hour,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, f
+}
+
+func TestProcessBatchRetriesFailedMail(t *testing.T) {
+	m := &fakeMailer{failFor: "bounce@example.com"}
+	w, f := newTestWorker(t, m)
+	sentID, failedID := uuid.New(), uuid.New()
+	payload := []byte(`{"token":"raw-token"}`)
+	f.pending = [][]driver.Value{
+		{sentID.String(), string(mailer.KindPasswordReset), "user@example.com", "ko", payload, int64(0)},
+		{failedID.String(), string(mailer.KindPasswordReset), "bounce@example.com", "ko", payload, int64(2)},
+	}
+
+	n, err := w.processBatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("processed %d messages, want 2", n)
+	}
+	if len(m.sent) != 1 || !strings.Contains(m.sent[0].Body, "raw-token") {
+		t.Fatalf("sent = %+v", m.sent)
+	}
+
+	if sent := f.calls["MarkOutboxMessageSent"]; len(sent) != 1 || sent[0][0] != sentID.String() {
+		t.Errorf("MarkOutboxMessageSent calls = %v", sent)
+	}
+	// 보낸 메일의 템플릿 데이터(토큰 원문)는 남기지 않는다.
+	if !strings.Contains(f.queries["MarkOutboxMessageSent"], "payload = '{}'") {
+		t.Error("MarkOutboxMessageSent keeps the payload")
+	}
+	failed := f.calls["MarkOutboxMessageFailed"]
+	if len(failed) != 1 || failed[0][0] != failedID.String() {
+		t.Fatalf("MarkOutboxMessageFailed calls = %v", failed)
+	}
+	if lastError, _ := failed[0][1].(string); !strings.Contains(lastError, "mailbox unavailable") {
+		t.Errorf("last_error = %v", failed[0][1])
+	}
+	// 세 번째 실패이므로 2분 뒤에 다시 시도한다.
+	if next := time.Until(failed[0][2].(time.Time)); next <= time.Minute || next > 2*time.Minute {
+		t.Errorf("next attempt in %v, want about 2m", next)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestPurgeDeletesFinishedMessagesPastRetention(t *testing.T) {
+	w, f := newTestWorker(t, &fakeMailer{})
+	if _, err := w.purge(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	calls := f.calls["DeleteFinishedOutboxMessages"]
+	if len(calls) != 1 {
+		t.Fatalf("DeleteFinishedOutboxMessages calls = %v", calls)
+	}
+	if age := time.Since(calls[0][0].(time.Time)); age < w.retention || age > w.retention+time.Minute {
+		t.Errorf("purged messages older than %v, want %v", age, w.retention)
+	}
+	if calls[0][1] != int64(w.maxAttempts) {
+		t.Errorf("max_attempts = %v, want %d", calls[0][1], w.maxAttempts)
+	}
+}
@@ -2,27 +2,50 @@ package service
 
 import (
 	"log/slog"
+	"net/netip"
 
 	"github.com/escape-ship/accountsrv/config"
+	"github.com/escape-ship/accountsrv/internal/auth"
 	"github.com/escape-ship/accountsrv/internal/infra/redis"
+	"github.com/escape-ship/accountsrv/internal/oauth"
 	"github.com/escape-ship/accountsrv/pkg/postgres"
-	pb "github.com/escape-ship/protos/gen"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
 )
 
 type AccountService struct {
 	pb.AccountServiceServer
-	pg          postgres.DBEngine
-	RedisClient *redis.RedisClient
-	config      *config.Config
-	logger      *slog.Logger
+	pg             postgres.DBEngine
+	RedisClient    *redis.RedisClient
+	keys           *auth.KeyRing
+	passwords      *auth.PasswordHasher
+	breached       *auth.BreachedCorpus
+	sealer         *auth.Sealer              // TOTP 비밀키와 제공자 토큰 암호화. 키가 설정되지 않으면 nil
+	providers      map[string]oauth.Provider // 설정된 소셜 로그인 제공자 (이름별)
+	trustedProxies []netip.Prefix            // x-forwarded-for 를 믿을 프록시 주소 (app.trusted_proxies)
+	config         *config.Config
+	logger         *slog.Logger
 }
 
-func NewAccountService(pg postgres.DBEngine, redisClient *redis.RedisClient, cfg *config.Config) *AccountService {
+func NewAccountService(pg postgres.DBEngine, redisClient *redis.RedisClient, keys *auth.KeyRing, breached *auth.BreachedCorpus, sealer *auth.Sealer, providers map[string]oauth.Provider, cfg *config.Config) *AccountService {
 	logger := slog.Default().With("service", "account")
+	// config.New 에서 이미 검증했다.
+	trustedProxies, _ := cfg.App.TrustedProxyPrefixes()
 	return &AccountService{
 		pg:          pg,
 		RedisClient: redisClient,
-		config:      cfg,
-		logger:      logger,
+		keys:        keys,
+		passwords: auth.NewPasswordHasher(auth.Argon2Params{
+			Memory:      cfg.Password.Hash.Memory,
+			Iterations:  cfg.Password.Hash.Iterations,
+			Parallelism: cfg.Password.Hash.Parallelism,
+			SaltLength:  cfg.Password.Hash.SaltLength,
+			KeyLength:   cfg.Password.Hash.KeyLength,
+		}),
+		breached:       breached,
+		sealer:         sealer,
+		providers:      providers,
+		trustedProxies: trustedProxies,
+		config:         cfg,
+		logger:         logger,
 	}
 }
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 토큰이 유효하지 않은 이유. verifyAccessToken 은 이 에러들 외에는 인프라 오류만 반환한다.
+var (
+	errInvalidAccessToken = errors.New("invalid access token")
+	errAccessTokenRevoked = errors.New("access token revoked")
+	errSessionInactive    = errors.New("session expired or revoked")
+)
+
+// isInactiveToken 토큰 자체가 유효하지 않아 발생한 에러인지 여부
+func isInactiveToken(err error) bool {
+	return errors.Is(err, errInvalidAccessToken) ||
+		errors.Is(err, errAccessTokenRevoked) ||
+		errors.Is(err, errSessionInactive)
+}
+
+// principal 인증된 요청의 주체
+type principal struct {
+	UserID        uuid.UUID
+	SessionID     uuid.UUID
+	TokenID       string
+	EmailVerified bool
+	Roles         []string
+	Scopes        []string
+	IssuedAt      time.Time
+	ExpiresAt     time.Time
+}
+
+// authenticate authorization 메타데이터의 액세스 토큰을 검증하고 요청 주체를 반환한다.
+// 인터셉터가 이미 검증한 요청이면 context 의 주체를 그대로 쓴다.
+// 반환되는 에러는 gRPC status 에러이다.
+func (s *AccountService) authenticate(ctx context.Context) (*principal, error) {
+	if p, ok := principalFromContext(ctx); ok {
+		return p, nil
+	}
+	logger := s.logger.With("method", "authenticate")
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+	p, err := s.verifyAccessToken(ctx, token)
+	if err != nil {
+		if isInactiveToken(err) {
+			logger.Debug("Rejected access token", slog.String("error", err.Error()))
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		logger.Error("Failed to verify access token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to verify access token: %v", err)
+	}
+	return p, nil
+}
+
+// verifyAccessToken 액세스 토큰을 검증하고 토큰 주체를 반환한다.
+// 서명/만료 외에 jti 거부 목록과 Redis 세션 상태(세션이 sub 사용자의 것인지 포함)까지 확인한다.
+func (s *AccountService) verifyAccessToken(ctx context.Context, token string) (*principal, error) {
+	claims, err := s.parseAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidAccessToken, err)
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, errInvalidAccessToken
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, errInvalidAccessToken
+	}
+
+	revoked, err := s.isAccessTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access token denylist: %w", err)
+	}
+	if revoked {
+		return nil, errAccessTokenRevoked
+	}
+
+	active, err := s.isSessionActive(ctx, userID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !active {
+		return nil, errSessionInactive
+	}
+
+	p := &principal{
+		UserID:        userID,
+		SessionID:     sessionID,
+		TokenID:       claims.ID,
+		EmailVerified: claims.EmailVerified,
+		Roles:         claims.Roles,
+		Scopes:        strings.Fields(claims.Scope),
+		ExpiresAt:     claims.ExpiresAt.Time,
+	}
+	if claims.IssuedAt != nil {
+		p.IssuedAt = claims.IssuedAt.Time
+	}
+	return p, nil
+}
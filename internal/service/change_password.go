@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ChangePassword 현재 사용자의 비밀번호를 바꾼다.
+// 카카오 로그인으로만 가입해 비밀번호가 없는 사용자는 현재 비밀번호 없이 처음 비밀번호를 설정한다.
+// 다만 비밀번호 로그인은 이메일로 하므로 이메일이 없는 사용자는 비밀번호를 설정할 수 없다.
+// 현재 비밀번호가 틀리면 로그인 실패와 같이 사용자/IP 단위로 실패를 기록한다.
+func (s *AccountService) ChangePassword(ctx context.Context, in *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	logger := s.logger.With("method", "ChangePassword")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Changing password")
+
+	_, ip := s.clientInfo(ctx)
+	userScope, ipScope := userThrottleScope(p.UserID), ipThrottleScope(ip)
+	if err := s.checkLoginThrottle(ctx, userScope, ipScope); err != nil {
+		logger.Warn("Password change rejected by throttle", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	var endedSessions []uuid.UUID
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			if commitErr := tx.Commit(); commitErr != nil {
+				logger.Error("Failed to commit transaction", slog.String("error", commitErr.Error()))
+				return
+			}
+			s.uncacheSessions(ctx, logger, endedSessions)
+		}
+	}()
+
+	user, err := qtx.GetUserByID(ctx, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+
+	// 1. 현재 비밀번호 검증 (비밀번호가 없는 소셜 로그인 사용자는 건너뜀)
+	if user.PasswordHash == "" {
+		if user.Email == "" {
+			logger.Warn("Cannot set password for user without email")
+			return nil, status.Errorf(codes.FailedPrecondition, "an email address is required to sign in with a password")
+		}
+		logger.Info("Setting first password for passwordless user")
+	} else {
+		logger.Debug("Verifying current password")
+		ok, _, verifyErr := s.passwords.Verify(in.CurrentPassword, user.PasswordHash)
+		if verifyErr != nil {
+			logger.Error("Failed to verify password", slog.String("error", verifyErr.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to verify password: %v", verifyErr)
+		}
+		if !ok {
+			logger.Warn("Invalid current password")
+			s.recordLoginFailureOrLog(ctx, logger, userScope, ipScope)
+			return nil, status.Errorf(codes.PermissionDenied, "current password is incorrect")
+		}
+	}
+
+	// 2. 새 비밀번호 저장
+	if err = s.validatePassword("new_password", in.NewPassword, user.Email); err != nil {
+		logger.Warn("New password rejected by policy")
+		return nil, err
+	}
+	passwordHash, err := s.hashPassword(in.NewPassword)
+	if err != nil {
+		logger.Error("Failed to hash password", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
+	}
+	if err = qtx.UpdateUserPassword(ctx, postgresql.UpdateUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		logger.Error("Failed to update password", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to update password: %v", err)
+	}
+	// 진행 중인 재설정 링크는 더 이상 쓸 수 없게 한다.
+	if err = qtx.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+		logger.Error("Failed to invalidate reset tokens", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to invalidate reset tokens: %v", err)
+	}
+
+	// 3. 다른 세션 로그아웃
+	if in.SignOutOtherSessions {
+		logger.Info("Signing out other sessions")
+		if endedSessions, err = s.endUserSessions(ctx, qtx, user.ID, p.SessionID); err != nil {
+			logger.Error("Failed to end other sessions", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to end other sessions: %v", err)
+		}
+	}
+
+	// 4. 변경 알림 메일
+	if err = s.enqueueMail(ctx, qtx, mailer.KindPasswordChanged, user.Email, nil); err != nil {
+		logger.Error("Failed to queue password changed mail", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to queue password changed mail: %v", err)
+	}
+
+	logger.Info("Password changed")
+	return &pb.ChangePasswordResponse{}, nil
+}
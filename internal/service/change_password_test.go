@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"net"
+	"testing"
+
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// withPeer ip 에서 연결한 요청의 context
+func withPeer(ctx context.Context, ip string) context.Context {
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+}
+
+func TestChangePassword(t *testing.T) {
+	s, db, _ := newTestService(t)
+	userID, passwordHash := passwordUser(t, s, db, "old correct horse")
+	store := newOneTimeTokenStore(t, db, "PasswordReset")
+	ctx, _, _ := signIn(t, s, userID, roleCustomer)
+
+	if _, err := s.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		CurrentPassword: "old correct horse",
+		NewPassword:     "new correct horse",
+	}); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if ok, _, _ := s.passwords.Verify("new correct horse", *passwordHash); !ok {
+		t.Error("password was not changed")
+	}
+	if db.called("InvalidatePasswordResetTokens") != 1 {
+		t.Error("pending reset tokens were not invalidated")
+	}
+	if db.called("RevokeUserSessions") != 0 {
+		t.Error("other sessions were signed out without sign_out_other_sessions")
+	}
+	if len(store.mailsOf(mailer.KindPasswordChanged)) != 1 {
+		t.Error("password changed mail was not queued")
+	}
+}
+
+func TestChangePasswordWrongCurrentPasswordIsThrottled(t *testing.T) {
+	s, db, fr := newTestService(t)
+	userID, _ := passwordUser(t, s, db, "old correct horse")
+	ctx, _, _ := signIn(t, s, userID, roleCustomer)
+	ctx = withPeer(ctx, "192.0.2.10")
+	wrong := &pb.ChangePasswordRequest{CurrentPassword: "guess", NewPassword: "new correct horse"}
+
+	// 허용 횟수까지는 틀렸다고만 답하고, 그 뒤로는 로그인과 같이 대기를 건다.
+	free := s.config.Auth.LoginThrottle.FreeAttempts
+	for i := 0; i <= free; i++ {
+		if _, err := s.ChangePassword(ctx, wrong); status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("attempt %d: got %v, want PermissionDenied", i+1, err)
+		}
+	}
+	for _, scope := range []string{userThrottleScope(userID), ipThrottleScope("192.0.2.10")} {
+		if failures, _ := fr.value("login_fail:" + scope); failures != "4" {
+			t.Errorf("%s failures = %q, want 4", scope, failures)
+		}
+	}
+	if _, err := s.ChangePassword(ctx, wrong); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("throttled attempt: got %v, want ResourceExhausted", err)
+	}
+	// 대기 중에는 맞는 비밀번호로도 바꿀 수 없다.
+	_, err := s.ChangePassword(ctx, &pb.ChangePasswordRequest{CurrentPassword: "old correct horse", NewPassword: "new correct horse"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("correct password while throttled: got %v, want ResourceExhausted", err)
+	}
+	if db.called("UpdateUserPassword") != 0 {
+		t.Error("password was changed")
+	}
+}
+
+func TestChangePasswordFirstPassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		want  codes.Code
+	}{
+		{"social user with email", "kakao@example.com", codes.OK},
+		// 이메일이 없으면 비밀번호로 로그인할 수 없으므로 설정하지 않는다.
+		{"social user without email", "", codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db, _ := newTestService(t)
+			userID := uuid.New()
+			db.handle("GetUserByID", func(args []driver.Value) ([][]driver.Value, error) {
+				return [][]driver.Value{{userID.String(), tt.email, "", nil}}, nil
+			})
+			db.handle("UpdateUserPassword", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+			newOneTimeTokenStore(t, db, "PasswordReset")
+			ctx, _, _ := signIn(t, s, userID, roleCustomer)
+
+			_, err := s.ChangePassword(ctx, &pb.ChangePasswordRequest{NewPassword: "new correct horse"})
+			if status.Code(err) != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if updated := db.called("UpdateUserPassword") == 1; updated != (tt.want == codes.OK) {
+				t.Errorf("password updated = %v", updated)
+			}
+		})
+	}
+}
+
+func TestChangePasswordRequiresAuthentication(t *testing.T) {
+	s, db, _ := newTestService(t)
+	passwordUser(t, s, db, "old correct horse")
+	_, err := s.ChangePassword(context.Background(), &pb.ChangePasswordRequest{NewPassword: "new correct horse"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got %v, want Unauthenticated", err)
+	}
+	if db.called("GetUserByID") != 0 {
+		t.Error("unauthenticated request reached the database")
+	}
+}
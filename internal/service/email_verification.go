@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// unverifiedLoginReject 미인증 계정의 로그인을 거부하는 설정값 (auth.unverified_login)
+const unverifiedLoginReject = "reject"
+
+// verificationResentMessage 이메일 존재/인증 여부와 관계없이 같은 응답을 준다.
+const verificationResentMessage = "If the email is registered and not yet verified, a verification email has been sent"
+
+// createEmailVerificationToken 사용하지 않은 기존 토큰을 무효화하고 새 인증 토큰을 저장한다.
+func (s *AccountService) createEmailVerificationToken(ctx context.Context, qtx *postgresql.Queries, userID uuid.UUID) (string, time.Time, error) {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(s.config.Auth.EmailVerificationTTL)
+	if err := qtx.InvalidateEmailVerificationTokens(ctx, userID); err != nil {
+		return "", time.Time{}, err
+	}
+	if err := qtx.InsertEmailVerificationToken(ctx, postgresql.InsertEmailVerificationTokenParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// enqueueVerificationEmail 인증 토큰을 만들어 인증 메일을 쌓는다.
+func (s *AccountService) enqueueVerificationEmail(ctx context.Context, qtx *postgresql.Queries, userID uuid.UUID, email string) error {
+	token, expiresAt, err := s.createEmailVerificationToken(ctx, qtx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to create verification token: %w", err)
+	}
+	return s.enqueueMail(ctx, qtx, mailer.KindEmailVerification, email, map[string]string{
+		"token":      token,
+		"expires_at": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// VerifyEmail 메일로 받은 인증 토큰을 확인하고 이메일을 인증 처리한다.
+func (s *AccountService) VerifyEmail(ctx context.Context, in *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	logger := s.logger.With("method", "VerifyEmail")
+	logger.Info("Verifying email")
+
+	if in.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	stored, err := qtx.GetEmailVerificationTokenForUpdate(ctx, auth.HashToken(in.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Warn("Unknown email verification token")
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired verification token")
+		}
+		logger.Error("Failed to get email verification token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get verification token: %v", err)
+	}
+	logger = logger.With("user_id", stored.UserID.String())
+	if stored.UsedAt.Valid || time.Now().After(stored.ExpiresAt) {
+		logger.Warn("Email verification token already used or expired")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid or expired verification token")
+	}
+
+	if err = qtx.MarkEmailVerified(ctx, stored.UserID); err != nil {
+		logger.Error("Failed to mark email verified", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+	}
+	if err = qtx.InvalidateEmailVerificationTokens(ctx, stored.UserID); err != nil {
+		logger.Error("Failed to invalidate verification tokens", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to invalidate verification tokens: %v", err)
+	}
+
+	logger.Info("Email verified")
+	return &pb.VerifyEmailResponse{}, nil
+}
+
+// ResendVerificationEmail 인증 메일을 다시 보낸다.
+// 같은 이메일로는 auth.verification_resend_interval 에 한 번만 요청할 수 있고,
+// 가입 여부가 드러나지 않도록 이메일이 없거나 이미 인증된 경우에도 같은 응답을 반환한다.
+func (s *AccountService) ResendVerificationEmail(ctx context.Context, in *pb.ResendVerificationEmailRequest) (*pb.ResendVerificationEmailResponse, error) {
+	logger := s.logger.With("method", "ResendVerificationEmail")
+	logger.Info("Verification email resend requested")
+
+	if in.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+	res := &pb.ResendVerificationEmailResponse{Message: verificationResentMessage}
+
+	// 1. 재발송 간격 제한 (가입 여부와 관계없이 이메일 단위로 적용)
+	throttleKey := fmt.Sprintf("verification_resend:%s", auth.HashToken(strings.ToLower(in.Email)))
+	ok, err := s.RedisClient.RedisClient.SetNX(ctx, throttleKey, "1", s.config.Auth.VerificationResendInterval).Result()
+	if err != nil {
+		logger.Error("Failed to check resend throttle", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to check resend throttle: %v", err)
+	}
+	if !ok {
+		logger.Warn("Verification email resend throttled")
+		return nil, status.Errorf(codes.ResourceExhausted, "verification email was sent recently, try again later")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	// 2. 미인증 사용자에게만 새 토큰 발송
+	user, err := qtx.GetUserByEmail(ctx, in.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Info("Verification email requested for unknown email")
+			return res, nil
+		}
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	logger = logger.With("user_id", user.ID.String())
+	if user.EmailVerifiedAt.Valid {
+		logger.Info("Email already verified")
+		return res, nil
+	}
+
+	if err = s.enqueueVerificationEmail(ctx, qtx, user.ID, user.Email); err != nil {
+		logger.Error("Failed to queue verification email", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to queue verification email: %v", err)
+	}
+
+	logger.Info("Verification email queued")
+	return res, nil
+}
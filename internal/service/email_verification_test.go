@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// unverifiedUser 이메일을 인증하지 않은 user@example.com 사용자를 등록한다. MarkEmailVerified 로 인증된다.
+func unverifiedUser(t *testing.T, s *AccountService, db *fakeDB, password string) uuid.UUID {
+	t.Helper()
+	userID := uuid.New()
+	passwordHash, err := s.hashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var verifiedAt driver.Value
+	db.handle("GetUserByEmail", func(args []driver.Value) ([][]driver.Value, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if args[0] != "user@example.com" {
+			return nil, nil
+		}
+		return [][]driver.Value{{userID.String(), "user@example.com", passwordHash, verifiedAt}}, nil
+	})
+	db.handle("MarkEmailVerified", func(args []driver.Value) ([][]driver.Value, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if args[0] != userID.String() {
+			t.Errorf("verified user %v, want %s", args[0], userID)
+		}
+		verifiedAt = time.Now()
+		return affected(1), nil
+	})
+	return userID
+}
+
+func TestEmailVerificationFlow(t *testing.T) {
+	s, db, _ := newTestService(t)
+	unverifiedUser(t, s, db, "correct horse")
+	store := newOneTimeTokenStore(t, db, "EmailVerification")
+	ctx := context.Background()
+
+	resp, err := s.ResendVerificationEmail(ctx, &pb.ResendVerificationEmailRequest{Email: "user@example.com"})
+	if err != nil {
+		t.Fatalf("ResendVerificationEmail: %v", err)
+	}
+	if resp.Message != verificationResentMessage {
+		t.Errorf("message = %q", resp.Message)
+	}
+	mails := store.mailsOf(mailer.KindEmailVerification)
+	if len(mails) != 1 {
+		t.Fatalf("got %d verification mails, want 1", len(mails))
+	}
+	token := mails[0].data["token"]
+	if len(store.tokens) != 1 || store.tokens[0].hash != auth.HashToken(token) {
+		t.Fatal("verification token is not stored by its hash")
+	}
+
+	if _, err := s.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: token}); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if db.called("MarkEmailVerified") != 1 {
+		t.Error("email was not marked verified")
+	}
+	// 같은 토큰은 두 번 쓸 수 없다.
+	if _, err := s.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: token}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("reused token: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestVerifyEmailRejected(t *testing.T) {
+	s, db, _ := newTestService(t)
+	userID := unverifiedUser(t, s, db, "correct horse")
+	store := newOneTimeTokenStore(t, db, "EmailVerification")
+	store.tokens = append(store.tokens, &oneTimeToken{
+		userID:    userID,
+		hash:      auth.HashToken("expired-token"),
+		expiresAt: time.Now().Add(-time.Minute),
+	})
+
+	for _, token := range []string{"expired-token", "unknown-token"} {
+		if _, err := s.VerifyEmail(context.Background(), &pb.VerifyEmailRequest{Token: token}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: got %v, want InvalidArgument", token, err)
+		}
+	}
+	if db.called("MarkEmailVerified") != 0 {
+		t.Error("email was verified with a rejected token")
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	s, db, _ := newTestService(t)
+	unverifiedUser(t, s, db, "correct horse")
+	store := newOneTimeTokenStore(t, db, "EmailVerification")
+	ctx := context.Background()
+
+	if _, err := s.ResendVerificationEmail(ctx, &pb.ResendVerificationEmailRequest{Email: "user@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	// 같은 이메일은 재발송 간격 안에 다시 요청할 수 없다. 대소문자만 다른 주소도 같다.
+	_, err := s.ResendVerificationEmail(ctx, &pb.ResendVerificationEmailRequest{Email: "User@Example.com"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("resend within interval: got %v, want ResourceExhausted", err)
+	}
+
+	// 가입하지 않은 이메일에도 같은 응답을 주고 메일은 보내지 않는다.
+	resp, err := s.ResendVerificationEmail(ctx, &pb.ResendVerificationEmailRequest{Email: "nobody@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != verificationResentMessage {
+		t.Errorf("message = %q", resp.Message)
+	}
+	if n := len(store.mailsOf(mailer.KindEmailVerification)); n != 1 {
+		t.Errorf("got %d verification mails, want 1", n)
+	}
+}
+
+func TestLoginRejectsUnverifiedEmail(t *testing.T) {
+	s, db, _ := newTestService(t)
+	s.config.Auth.UnverifiedLogin = unverifiedLoginReject
+	unverifiedUser(t, s, db, "correct horse")
+
+	_, err := s.Login(context.Background(), &pb.LoginRequest{Email: "user@example.com", Password: "correct horse"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("got %v, want FailedPrecondition", err)
+	}
+	if db.called("InsertSession") != 0 {
+		t.Error("session was created for an unverified email")
+	}
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/config"
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/redis"
+	"github.com/escape-ship/accountsrv/pkg/postgres"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// 서비스 테스트용 가짜 Redis 와 Postgres. 외부 서버 없이 RPC 를 끝까지 실행한다.
+
+// fakeRedis 서비스가 쓰는 명령만 구현한 메모리 Redis (RESP2)
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]*fakeRedisEntry
+}
+
+type fakeRedisEntry struct {
+	value   string
+	hash    map[string]string
+	expires time.Time
+}
+
+// newFakeRedis 테스트가 끝나면 닫히는 가짜 Redis 와 그 클라이언트
+func newFakeRedis(t *testing.T) (*fakeRedis, *redis.RedisClient) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	r := &fakeRedis{data: make(map[string]*fakeRedisEntry)}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	client := goredis.NewClient(&goredis.Options{
+		Addr:             lis.Addr().String(),
+		Protocol:         2,
+		DisableIndentity: true,
+	})
+	t.Cleanup(func() {
+		client.Close()
+		lis.Close()
+	})
+	return r, &redis.RedisClient{RedisClient: client}
+}
+
+// ttl key 의 남은 시간. 없으면 -2, 만료가 없으면 -1 이다.
+func (r *fakeRedis) ttl(key string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := r.get(key)
+	switch {
+	case e == nil:
+		return -2
+	case e.expires.IsZero():
+		return -1
+	}
+	return time.Until(e.expires)
+}
+
+// value key 의 문자열 값
+func (r *fakeRedis) value(key string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := r.get(key)
+	if e == nil {
+		return "", false
+	}
+	return e.value, true
+}
+
+func (r *fakeRedis) get(key string) *fakeRedisEntry {
+	e, ok := r.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expires.IsZero() && !time.Now().Before(e.expires) {
+		delete(r.data, key)
+		return nil
+	}
+	return e
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	var queued [][]string
+	multi := false
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "MULTI":
+			multi, queued = true, nil
+			reply = "+OK\r\n"
+		case "EXEC":
+			reply = fmt.Sprintf("*%d\r\n", len(queued))
+			for _, cmd := range queued {
+				reply += r.do(cmd)
+			}
+			multi, queued = false, nil
+		default:
+			if multi {
+				queued = append(queued, args)
+				reply = "+QUEUED\r\n"
+			} else {
+				reply = r.do(args)
+			}
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func respBulk(v string) string { return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v) }
+func respInt(n int64) string   { return fmt.Sprintf(":%d\r\n", n) }
+
+const respNil = "$-1\r\n"
+
+func (r *fakeRedis) do(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		e := &fakeRedisEntry{value: args[2]}
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "EX", "PX":
+				n, _ := strconv.ParseInt(args[i+1], 10, 64)
+				unit := time.Second
+				if strings.EqualFold(args[i], "PX") {
+					unit = time.Millisecond
+				}
+				e.expires = time.Now().Add(time.Duration(n) * unit)
+				i++
+			case "NX":
+				nx = true
+			case "KEEPTTL":
+				if old := r.get(args[1]); old != nil {
+					e.expires = old.expires
+				}
+			}
+		}
+		if nx && r.get(args[1]) != nil {
+			return respNil
+		}
+		r.data[args[1]] = e
+		return "+OK\r\n"
+	case "GET", "GETDEL":
+		e := r.get(args[1])
+		if e == nil {
+			return respNil
+		}
+		if strings.EqualFold(args[0], "GETDEL") {
+			delete(r.data, args[1])
+		}
+		return respBulk(e.value)
+	case "DEL", "EXISTS":
+		var n int64
+		for _, key := range args[1:] {
+			if r.get(key) != nil {
+				n++
+				if strings.EqualFold(args[0], "DEL") {
+					delete(r.data, key)
+				}
+			}
+		}
+		return respInt(n)
+	case "PTTL":
+		e := r.get(args[1])
+		switch {
+		case e == nil:
+			return respInt(-2)
+		case e.expires.IsZero():
+			return respInt(-1)
+		}
+		return respInt(time.Until(e.expires).Milliseconds())
+	case "EXPIRE":
+		e := r.get(args[1])
+		if e == nil {
+			return respInt(0)
+		}
+		n, _ := strconv.ParseInt(args[2], 10, 64)
+		e.expires = time.Now().Add(time.Duration(n) * time.Second)
+		return respInt(1)
+	case "INCR":
+		e := r.get(args[1])
+		if e == nil {
+			e = &fakeRedisEntry{value: "0"}
+			r.data[args[1]] = e
+		}
+		n, _ := strconv.ParseInt(e.value, 10, 64)
+		n++
+		e.value = strconv.FormatInt(n, 10)
+		return respInt(n)
+	case "HSET", "HINCRBY", "HGETALL":
+		e := r.get(args[1])
+		if e == nil {
+			e = &fakeRedisEntry{hash: make(map[string]string)}
+			if !strings.EqualFold(args[0], "HGETALL") {
+				r.data[args[1]] = e
+			}
+		}
+		switch strings.ToUpper(args[0]) {
+		case "HSET":
+			var added int64
+			for i := 2; i+1 < len(args); i += 2 {
+				if _, ok := e.hash[args[i]]; !ok {
+					added++
+				}
+				e.hash[args[i]] = args[i+1]
+			}
+			return respInt(added)
+		case "HINCRBY":
+			n, _ := strconv.ParseInt(e.hash[args[2]], 10, 64)
+			by, _ := strconv.ParseInt(args[3], 10, 64)
+			n += by
+			e.hash[args[2]] = strconv.FormatInt(n, 10)
+			return respInt(n)
+		}
+		reply := fmt.Sprintf("*%d\r\n", len(e.hash)*2)
+		for field, value := range e.hash {
+			reply += respBulk(field) + respBulk(value)
+		}
+		return reply
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+// fakeQuery sqlc 쿼리 하나의 가짜 구현. 조회는 결과 행을, 변경은 바뀐 행 수만큼의 (빈) 행을 반환한다.
+type fakeQuery func(args []driver.Value) ([][]driver.Value, error)
+
+// fakeDB sqlc 가 쿼리 앞에 붙이는 "-- name: <Query>" 주석으로 테스트가 등록한 구현을 찾는 database/sql 드라이버.
+// 트랜잭션은 흉내만 내고 롤백하지 않는다.
+type fakeDB struct {
+	mu      sync.Mutex
+	queries map[string]fakeQuery
+	calls   []string
+	db      *sql.DB
+}
+
+var _ postgres.DBEngine = (*fakeDB)(nil)
+
+func newFakeDB(t *testing.T) *fakeDB {
+	t.Helper()
+	f := &fakeDB{queries: make(map[string]fakeQuery)}
+	f.db = sql.OpenDB(f)
+	t.Cleanup(func() { f.db.Close() })
+	return f
+}
+
+// handle name 쿼리의 구현을 등록한다.
+func (f *fakeDB) handle(name string, q fakeQuery) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries[name] = q
+}
+
+// called name 쿼리가 실행된 횟수
+func (f *fakeDB) called(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, call := range f.calls {
+		if call == name {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeDB) Configure(opts ...postgres.Option) postgres.DBEngine { return f }
+func (f *fakeDB) GetDB() *sql.DB                                      { return f.db }
+func (f *fakeDB) Close()                                              {}
+
+var queryName = regexp.MustCompile(`-- name: (\w+)`)
+
+func (f *fakeDB) run(query string, named []driver.NamedValue) ([][]driver.Value, error) {
+	m := queryName.FindStringSubmatch(query)
+	if m == nil {
+		return nil, fmt.Errorf("fakedb: query without name: %s", query)
+	}
+	f.mu.Lock()
+	q, ok := f.queries[m[1]]
+	f.calls = append(f.calls, m[1])
+	f.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("fakedb: unexpected query %s", m[1])
+	}
+	args := make([]driver.Value, len(named))
+	for i, v := range named {
+		args[i] = v.Value
+	}
+	return q(args)
+}
+
+// database/sql/driver 구현
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepare not supported")
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// affected 변경 쿼리가 n 행을 바꿨다는 결과
+func affected(n int) [][]driver.Value {
+	return make([][]driver.Value, n)
+}
+
+// testKeyStore 활성 ES256 키 하나를 제공하는 키 저장소
+type testKeyStore struct{ key *auth.SigningKey }
+
+func (s testKeyStore) Load(ctx context.Context) ([]*auth.SigningKey, error) {
+	return []*auth.SigningKey{s.key}, nil
+}
+func (s testKeyStore) Add(ctx context.Context, key *auth.SigningKey) error { return nil }
+func (s testKeyStore) Promote(ctx context.Context, kid string) error       { return nil }
+
+// testConfig 테스트용 설정 (config.yaml 기본값과 같은 값)
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Auth.Issuer = "https://accounts.test"
+	cfg.Auth.AccessTokenTTL = 15 * time.Minute
+	cfg.Auth.RefreshTokenTTL = 336 * time.Hour
+	cfg.Auth.PasswordResetTTL = 30 * time.Minute
+	cfg.Auth.EmailVerificationTTL = 24 * time.Hour
+	cfg.Auth.VerificationResendInterval = time.Minute
+	cfg.Auth.UnverifiedLogin = "flag"
+	cfg.Auth.OAuthStateTTL = 10 * time.Minute
+	cfg.Auth.MFAChallengeTTL = 5 * time.Minute
+	cfg.Auth.MFAMaxAttempts = 5
+	cfg.Auth.WebAuthn = config.WebAuthn{
+		RPID:          "localhost",
+		RPDisplayName: "Escape Ship",
+		RPOrigins:     []string{"http://localhost:3000"},
+		ChallengeTTL:  5 * time.Minute,
+	}
+	cfg.Auth.LoginThrottle = config.LoginThrottle{
+		FreeAttempts:       3,
+		BaseDelay:          time.Second,
+		MaxDelay:           5 * time.Minute,
+		Window:             15 * time.Minute,
+		LockoutThreshold:   10,
+		IPLockoutThreshold: 100,
+		LockoutDuration:    30 * time.Minute,
+	}
+	// 테스트에서는 가벼운 argon2id 파라미터를 쓴다.
+	cfg.Password.Hash = config.PasswordHash{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	return cfg
+}
+
+// newTestService 가짜 Postgres/Redis 와 ES256 키로 만든 AccountService
+func newTestService(t *testing.T) (*AccountService, *fakeDB, *fakeRedis) {
+	t.Helper()
+	key, err := auth.GenerateSigningKey("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.ActivatedAt = time.Now().Add(-time.Hour)
+	keys, err := auth.NewKeyRing(context.Background(), testKeyStore{key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newFakeDB(t)
+	fr, rc := newFakeRedis(t)
+	s := NewAccountService(db, rc, keys, nil, nil, nil, testConfig())
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return s, db, fr
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func identityToProto(identity postgresql.AccountUserIdentity) *pb.Identity {
+	res := &pb.Identity{
+		IdentityId: identity.ID.String(),
+		Provider:   identity.Provider,
+		Email:      identity.Email,
+		CreatedAt:  timestamppb.New(identity.CreatedAt),
+	}
+	if identity.LastLoginAt.Valid {
+		res.LastLoginAt = timestamppb.New(identity.LastLoginAt.Time)
+	}
+	return res
+}
+
+// ListIdentities 현재 사용자에 연결된 소셜 계정 목록을 반환한다.
+func (s *AccountService) ListIdentities(ctx context.Context, in *pb.ListIdentitiesRequest) (*pb.ListIdentitiesResponse, error) {
+	logger := s.logger.With("method", "ListIdentities")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Debug("Listing identities")
+
+	querier := postgresql.New(s.pg.GetDB())
+	identities, err := querier.ListUserIdentitiesByUser(ctx, p.UserID)
+	if err != nil {
+		logger.Error("Failed to list identities", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to list identities: %v", err)
+	}
+
+	res := &pb.ListIdentitiesResponse{Identities: make([]*pb.Identity, 0, len(identities))}
+	for _, identity := range identities {
+		res.Identities = append(res.Identities, identityToProto(identity))
+	}
+	return res, nil
+}
+
+// BeginIdentityLink 현재 사용자에 소셜 계정을 연결하기 위한 제공자 로그인 URL 을 반환한다.
+// state 에 사용자를 묶어 두므로 다른 사용자나 로그인 흐름에서는 이 state 를 쓸 수 없다.
+func (s *AccountService) BeginIdentityLink(ctx context.Context, in *pb.BeginIdentityLinkRequest) (*pb.BeginIdentityLinkResponse, error) {
+	logger := s.logger.With("method", "BeginIdentityLink", "provider", in.Provider)
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Beginning identity link")
+
+	provider, err := s.provider(in.Provider)
+	if err != nil {
+		return nil, err
+	}
+	req, _, err := s.saveOAuthState(ctx, provider.Name(), p.UserID)
+	if err != nil {
+		logger.Error("Failed to store oauth state", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store oauth state: %v", err)
+	}
+	return &pb.BeginIdentityLinkResponse{LoginUrl: provider.AuthCodeURL(req)}, nil
+}
+
+// FinishIdentityLink 제공자 콜백의 code, state 로 소셜 계정을 현재 사용자에 연결한다.
+// 제공자마다 계정 하나만 연결할 수 있고, 다른 사용자에 연결된 계정은 연결할 수 없다.
+func (s *AccountService) FinishIdentityLink(ctx context.Context, in *pb.FinishIdentityLinkRequest) (*pb.FinishIdentityLinkResponse, error) {
+	logger := s.logger.With("method", "FinishIdentityLink", "provider", in.Provider)
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Finishing identity link")
+
+	provider, err := s.provider(in.Provider)
+	if err != nil {
+		return nil, err
+	}
+	token, profile, err := s.socialProfile(ctx, logger, provider, p.UserID, in.Code, in.State, "")
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	params := postgresql.GetUserIdentityParams{
+		Provider:       profile.Provider,
+		ProviderUserID: profile.Subject,
+	}
+
+	// 1. 이미 연결된 계정인지 확인
+	existing, err := qtx.GetUserIdentity(ctx, params)
+	if err == nil {
+		if existing.UserID != p.UserID {
+			logger.Warn("Provider account already linked to another user")
+			return nil, status.Errorf(codes.AlreadyExists, "%s account is already linked to another user", profile.Provider)
+		}
+		logger.Info("Provider account already linked")
+		if err = s.saveProviderToken(ctx, qtx, existing.ID, token); err != nil {
+			logger.Error("Failed to store provider token", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to store provider token: %v", err)
+		}
+		return &pb.FinishIdentityLinkResponse{Identity: identityToProto(existing)}, nil
+	}
+	if err != sql.ErrNoRows {
+		logger.Error("Failed to get identity", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get identity: %v", err)
+	}
+	identities, err := qtx.ListUserIdentitiesByUser(ctx, p.UserID)
+	if err != nil {
+		logger.Error("Failed to list identities", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to list identities: %v", err)
+	}
+	for _, linked := range identities {
+		if linked.Provider == profile.Provider {
+			logger.Warn("Another account of the provider is already linked")
+			return nil, status.Errorf(codes.AlreadyExists, "another %s account is already linked, unlink it first", profile.Provider)
+		}
+	}
+
+	// 2. 연결
+	identityID, err := s.linkIdentity(ctx, qtx, p.UserID, profile.Provider, profile.Subject, profile.Email)
+	if err != nil {
+		logger.Error("Failed to link identity", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to link identity: %v", err)
+	}
+	if err = s.saveProviderToken(ctx, qtx, identityID, token); err != nil {
+		logger.Error("Failed to store provider token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store provider token: %v", err)
+	}
+	linked, err := qtx.GetUserIdentity(ctx, params)
+	if err != nil {
+		logger.Error("Failed to get identity", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get identity: %v", err)
+	}
+
+	logger.Info("Identity linked", slog.String("identity_id", linked.ID.String()))
+	return &pb.FinishIdentityLinkResponse{Identity: identityToProto(linked)}, nil
+}
+
+// UnlinkIdentity 현재 사용자의 소셜 계정 연결을 해제한다.
+// 비밀번호와 패스키가 없는 계정의 마지막 소셜 계정은 로그인할 방법이 없어지므로 거부한다.
+// 이메일이 없는 계정은 비밀번호로 로그인할 수 없으므로 비밀번호가 있어도 없는 것으로 본다.
+func (s *AccountService) UnlinkIdentity(ctx context.Context, in *pb.UnlinkIdentityRequest) (*pb.UnlinkIdentityResponse, error) {
+	logger := s.logger.With("method", "UnlinkIdentity", "identity_id", in.IdentityId)
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Unlinking identity")
+
+	identityID, err := uuid.Parse(in.IdentityId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid identity id")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	// 1. 동시에 여러 연결을 해제해 로그인 수단이 모두 사라지지 않도록 사용자 행을 잠근다.
+	user, err := qtx.GetUserByIDForUpdate(ctx, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	identities, err := qtx.ListUserIdentitiesByUser(ctx, p.UserID)
+	if err != nil {
+		logger.Error("Failed to list identities", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to list identities: %v", err)
+	}
+	found := false
+	for _, identity := range identities {
+		if identity.ID == identityID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		logger.Warn("Identity not found")
+		return nil, status.Errorf(codes.NotFound, "identity not found")
+	}
+
+	// 2. 남는 로그인 수단 확인. 비밀번호는 이메일로 로그인하므로 이메일이 없는 계정의 비밀번호는 세지 않는다.
+	hasPassword := user.Email != "" && user.PasswordHash != ""
+	if len(identities) == 1 && !hasPassword {
+		credentials, err := qtx.ListWebAuthnCredentialsByUser(ctx, p.UserID)
+		if err != nil {
+			logger.Error("Failed to list passkeys", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to list passkeys: %v", err)
+		}
+		if len(credentials) == 0 {
+			logger.Warn("Refusing to unlink the last sign-in method")
+			return nil, status.Errorf(codes.FailedPrecondition, "cannot unlink the last sign-in method, set a password or add a passkey first")
+		}
+	}
+
+	// 3. 해제
+	if _, err = qtx.DeleteUserIdentity(ctx, postgresql.DeleteUserIdentityParams{
+		ID:     identityID,
+		UserID: p.UserID,
+	}); err != nil {
+		logger.Error("Failed to unlink identity", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to unlink identity: %v", err)
+	}
+
+	logger.Info("Identity unlinked")
+	return &pb.UnlinkIdentityResponse{}, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnlinkIdentity(t *testing.T) {
+	tests := []struct {
+		name       string
+		email      string
+		password   string
+		passkey    bool
+		identities int
+		want       codes.Code
+	}{
+		{"password", "user@example.com", "correct horse", false, 1, codes.OK},
+		{"passkey", "", "", true, 1, codes.OK},
+		{"another identity", "", "", false, 2, codes.OK},
+		{"last sign-in method", "user@example.com", "", false, 1, codes.FailedPrecondition},
+		// 이메일이 없으면 비밀번호로 로그인할 수 없으므로 비밀번호를 설정했어도 잠긴다.
+		{"kakao user without email with password", "", "correct horse", false, 1, codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db, _ := newTestService(t)
+			store := newIdentityStore(db)
+			userID := store.addUser(t, s, tt.email, tt.password, true)
+			identityID := store.addIdentity(userID, oauth.ProviderKakao, "kakao-1", tt.email)
+			if tt.identities > 1 {
+				store.addIdentity(userID, oauth.ProviderGoogle, "google-1", "user@gmail.com")
+			}
+			if tt.passkey {
+				db.handle("ListWebAuthnCredentialsByUser", func(args []driver.Value) ([][]driver.Value, error) {
+					return [][]driver.Value{{
+						uuid.NewString(), userID.String(), []byte("credential"), []byte("public key"), "none", []byte{},
+						int64(0), "", false, false, "test key", time.Now(), nil,
+					}}, nil
+				})
+			}
+			ctx, _, _ := signIn(t, s, userID, roleCustomer)
+
+			_, err := s.UnlinkIdentity(ctx, &pb.UnlinkIdentityRequest{IdentityId: identityID.String()})
+			if status.Code(err) != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if unlinked := len(store.identitiesOf(userID)) == tt.identities-1; unlinked != (tt.want == codes.OK) {
+				t.Errorf("unlinked = %v, identities = %+v", unlinked, store.identitiesOf(userID))
+			}
+		})
+	}
+}
+
+func TestUnlinkIdentityOfAnotherUser(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newIdentityStore(db)
+	userID := store.addUser(t, s, "user@example.com", "correct horse", true)
+	otherID := store.addUser(t, s, "other@example.com", "correct horse", true)
+	otherIdentity := store.addIdentity(otherID, oauth.ProviderKakao, "kakao-2", "other@example.com")
+	ctx, _, _ := signIn(t, s, userID, roleCustomer)
+
+	_, err := s.UnlinkIdentity(ctx, &pb.UnlinkIdentityRequest{IdentityId: otherIdentity.String()})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+	if len(store.identitiesOf(otherID)) != 1 {
+		t.Error("another user's identity was unlinked")
+	}
+}
+
+func TestIdentityLinkFlow(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newIdentityStore(db)
+	userID := store.addUser(t, s, "user@example.com", "correct horse", true)
+	provider := withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1"})
+	ctx, _, _ := signIn(t, s, userID, roleCustomer)
+
+	// link 는 로그인한 사용자로 시작한 state 로 끝낸다.
+	finish := func(ctx context.Context) (*pb.FinishIdentityLinkResponse, error) {
+		begin, err := s.BeginIdentityLink(ctx, &pb.BeginIdentityLinkRequest{Provider: oauth.ProviderKakao})
+		if err != nil {
+			t.Fatalf("BeginIdentityLink: %v", err)
+		}
+		return s.FinishIdentityLink(ctx, &pb.FinishIdentityLinkRequest{
+			Provider: oauth.ProviderKakao,
+			Code:     "code",
+			State:    stateOf(t, begin.LoginUrl),
+		})
+	}
+	linked, err := finish(ctx)
+	if err != nil {
+		t.Fatalf("FinishIdentityLink: %v", err)
+	}
+	list, err := s.ListIdentities(ctx, &pb.ListIdentitiesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Identities) != 1 || list.Identities[0].IdentityId != linked.Identity.IdentityId || list.Identities[0].Provider != oauth.ProviderKakao {
+		t.Errorf("identities = %v, want the linked kakao account", list.Identities)
+	}
+
+	// 이미 다른 사용자에 연결된 제공자 계정은 연결할 수 없다.
+	otherID := store.addUser(t, s, "other@example.com", "correct horse", true)
+	otherCtx, _, _ := signIn(t, s, otherID, roleCustomer)
+	if _, err := finish(otherCtx); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("provider account of another user: got %v, want AlreadyExists", err)
+	}
+	// 같은 제공자의 다른 계정은 먼저 해제해야 한다.
+	provider.profile.Subject = "kakao-2"
+	if _, err := finish(ctx); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("second kakao account: got %v, want AlreadyExists", err)
+	}
+	if len(store.identitiesOf(userID)) != 1 || len(store.identitiesOf(otherID)) != 0 {
+		t.Error("rejected link changed the identities")
+	}
+}
+
+// stateOf 제공자 로그인 URL 의 state
+func stateOf(t *testing.T, loginURL string) string {
+	t.Helper()
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("state")
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// accessLevel RPC 호출에 필요한 인증 수준
+type accessLevel int
+
+const (
+	accessAuthenticated accessLevel = iota // 유효한 액세스 토큰 필요 (기본값)
+	accessPublic                           // 인증 없이 호출 가능
+	accessRole                             // 액세스 토큰 + 특정 역할 필요
+)
+
+// methodPolicy RPC 별 접근 정책
+type methodPolicy struct {
+	level accessLevel
+	role  string // level 이 accessRole 일 때 필요한 역할
+}
+
+var (
+	publicMethod        = methodPolicy{level: accessPublic}
+	authenticatedMethod = methodPolicy{level: accessAuthenticated}
+)
+
+func roleMethod(role string) methodPolicy {
+	return methodPolicy{level: accessRole, role: role}
+}
+
+// methodPolicies AccountService RPC 접근 정책.
+// 여기 없는 AccountService RPC 는 authenticated 로 취급하므로 새 RPC 가 실수로 공개되지 않는다.
+var methodPolicies = map[string]methodPolicy{
+	pb.AccountService_GetKakaoLoginURL_FullMethodName:          publicMethod,
+	pb.AccountService_GetKakaoCallBack_FullMethodName:          publicMethod,
+	pb.AccountService_GetSocialLoginURL_FullMethodName:         publicMethod,
+	pb.AccountService_SocialCallback_FullMethodName:            publicMethod,
+	pb.AccountService_ConfirmSocialLink_FullMethodName:         publicMethod,
+	pb.AccountService_Login_FullMethodName:                     publicMethod,
+	pb.AccountService_Register_FullMethodName:                  publicMethod,
+	pb.AccountService_RefreshToken_FullMethodName:              publicMethod,
+	pb.AccountService_RequestPasswordReset_FullMethodName:      publicMethod,
+	pb.AccountService_ConfirmPasswordReset_FullMethodName:      publicMethod,
+	pb.AccountService_VerifyEmail_FullMethodName:               publicMethod,
+	pb.AccountService_ResendVerificationEmail_FullMethodName:   publicMethod,
+	pb.AccountService_BeginPasskeyLogin_FullMethodName:         publicMethod,
+	pb.AccountService_FinishPasskeyLogin_FullMethodName:        publicMethod,
+	pb.AccountService_VerifyMFA_FullMethodName:                 publicMethod,
+	pb.AccountService_GetJWKS_FullMethodName:                   publicMethod,
+	pb.AccountService_Logout_FullMethodName:                    authenticatedMethod,
+	pb.AccountService_ListSessions_FullMethodName:              authenticatedMethod,
+	pb.AccountService_RevokeSession_FullMethodName:             authenticatedMethod,
+	pb.AccountService_ChangePassword_FullMethodName:            authenticatedMethod,
+	pb.AccountService_BeginPasskeyRegistration_FullMethodName:  authenticatedMethod,
+	pb.AccountService_FinishPasskeyRegistration_FullMethodName: authenticatedMethod,
+	pb.AccountService_EnrollTOTP_FullMethodName:                authenticatedMethod,
+	pb.AccountService_ConfirmTOTP_FullMethodName:               authenticatedMethod,
+	pb.AccountService_DisableTOTP_FullMethodName:               authenticatedMethod,
+	pb.AccountService_ListIdentities_FullMethodName:            authenticatedMethod,
+	pb.AccountService_BeginIdentityLink_FullMethodName:         authenticatedMethod,
+	pb.AccountService_FinishIdentityLink_FullMethodName:        authenticatedMethod,
+	pb.AccountService_UnlinkIdentity_FullMethodName:            authenticatedMethod,
+	pb.AccountService_GrantRole_FullMethodName:                 roleMethod(roleAdmin),
+	pb.AccountService_RevokeRole_FullMethodName:                roleMethod(roleAdmin),
+	pb.AccountService_UnlockUser_FullMethodName:                roleMethod(roleAdmin),
+	pb.AccountService_ValidateToken_FullMethodName:             roleMethod(roleService),
+	pb.AccountService_GetProviderAccessToken_FullMethodName:    roleMethod(roleService),
+}
+
+// policyFor fullMethod 의 접근 정책. AccountService 밖의 서비스(reflection 등)는 공개한다.
+func policyFor(fullMethod string) methodPolicy {
+	if policy, ok := methodPolicies[fullMethod]; ok {
+		return policy
+	}
+	if strings.HasPrefix(fullMethod, "/"+pb.AccountService_ServiceDesc.ServiceName+"/") {
+		return authenticatedMethod
+	}
+	return publicMethod
+}
+
+type principalKey struct{}
+
+// principalFromContext 인터셉터가 넣어 둔 요청 주체
+func principalFromContext(ctx context.Context) (*principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*principal)
+	return p, ok
+}
+
+// authorize 정책에 따라 요청을 인증하고, 인증된 경우 주체를 담은 context 를 반환한다.
+func (s *AccountService) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	policy := policyFor(fullMethod)
+	if policy.level == accessPublic {
+		return ctx, nil
+	}
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policy.level == accessRole && !p.hasRole(policy.role) {
+		s.logger.Warn("Permission denied",
+			"method", fullMethod,
+			"user_id", p.UserID.String(),
+			"required_role", policy.role)
+		return nil, status.Errorf(codes.PermissionDenied, "%s role required", policy.role)
+	}
+	return context.WithValue(ctx, principalKey{}, p), nil
+}
+
+// UnaryInterceptor 메서드 정책에 따라 액세스 토큰을 검증하는 unary 인터셉터
+func (s *AccountService) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := s.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor 메서드 정책에 따라 액세스 토큰을 검증하는 stream 인터셉터
+func (s *AccountService) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := s.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticatedStream 주체가 담긴 context 를 돌려주는 ServerStream
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPolicyFor(t *testing.T) {
+	tests := []struct {
+		method string
+		want   methodPolicy
+	}{
+		{pb.AccountService_Login_FullMethodName, publicMethod},
+		{pb.AccountService_RefreshToken_FullMethodName, publicMethod},
+		{pb.AccountService_GetJWKS_FullMethodName, publicMethod},
+		{pb.AccountService_Logout_FullMethodName, authenticatedMethod},
+		{pb.AccountService_ChangePassword_FullMethodName, authenticatedMethod},
+		{pb.AccountService_GrantRole_FullMethodName, roleMethod(roleAdmin)},
+		{pb.AccountService_UnlockUser_FullMethodName, roleMethod(roleAdmin)},
+		{pb.AccountService_GetProviderAccessToken_FullMethodName, roleMethod(roleService)},
+		// 정책이 없는 AccountService RPC 는 인증을 요구한다.
+		{"/" + pb.AccountService_ServiceDesc.ServiceName + "/NewMethod", authenticatedMethod},
+		{"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", publicMethod},
+	}
+	for _, tt := range tests {
+		if got := policyFor(tt.method); got != tt.want {
+			t.Errorf("policyFor(%s) = %+v, want %+v", tt.method, got, tt.want)
+		}
+	}
+}
+
+// 새 RPC 를 추가하면 methodPolicies 에도 정책을 적어야 한다.
+func TestEveryRPCHasPolicy(t *testing.T) {
+	for _, m := range pb.AccountService_ServiceDesc.Methods {
+		method := "/" + pb.AccountService_ServiceDesc.ServiceName + "/" + m.MethodName
+		if _, ok := methodPolicies[method]; !ok {
+			t.Errorf("%s has no access policy", method)
+		}
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	s, _, _ := newTestService(t)
+	interceptor := s.UnaryInterceptor()
+	userID := uuid.New()
+	ctx, _, token := signIn(t, s, userID, roleCustomer)
+	claims, err := s.parseAccessToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(ctx context.Context, method string) (*principal, bool, error) {
+		var p *principal
+		called := false
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			p, _ = principalFromContext(ctx)
+			return nil, nil
+		})
+		return p, called, err
+	}
+
+	if _, called, err := call(context.Background(), pb.AccountService_Login_FullMethodName); err != nil || !called {
+		t.Errorf("public method without token: called=%v err=%v", called, err)
+	}
+	if _, called, err := call(context.Background(), pb.AccountService_Logout_FullMethodName); status.Code(err) != codes.Unauthenticated || called {
+		t.Errorf("authenticated method without token: called=%v err=%v", called, err)
+	}
+	p, called, err := call(ctx, pb.AccountService_Logout_FullMethodName)
+	if err != nil || !called {
+		t.Fatalf("authenticated method with token: called=%v err=%v", called, err)
+	}
+	if p == nil || p.UserID != userID {
+		t.Errorf("handler principal = %+v, want user %s", p, userID)
+	}
+	if _, called, err := call(ctx, pb.AccountService_GrantRole_FullMethodName); status.Code(err) != codes.PermissionDenied || called {
+		t.Errorf("admin method as customer: called=%v err=%v", called, err)
+	}
+
+	// 거부 목록에 오른 토큰은 더 이상 통과하지 않는다.
+	if err := s.revokeAccessToken(context.Background(), claims.ID, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, called, err := call(ctx, pb.AccountService_Logout_FullMethodName); status.Code(err) != codes.Unauthenticated || called {
+		t.Errorf("revoked token: called=%v err=%v", called, err)
+	}
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+)
+
+// GetJWKS 토큰 검증용 공개키 목록을 반환한다.
+func (s *AccountService) GetJWKS(ctx context.Context, in *pb.GetJWKSRequest) (*pb.GetJWKSResponse, error) {
+	jwks := s.keys.JWKS()
+	res := &pb.GetJWKSResponse{Keys: make([]*pb.JSONWebKey, 0, len(jwks.Keys))}
+	for _, k := range jwks.Keys {
+		res.Keys = append(res.Keys, &pb.JSONWebKey{
+			Kty: k.Kty,
+			Use: k.Use,
+			Kid: k.Kid,
+			Alg: k.Alg,
+			N:   k.N,
+			E:   k.E,
+			Crv: k.Crv,
+			X:   k.X,
+			Y:   k.Y,
+		})
+	}
+	return res, nil
+}
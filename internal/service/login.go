@@ -3,23 +3,30 @@ package service
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"log/slog"
-	"time"
 
 	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
-	pb "github.com/escape-ship/protos/gen"
-	"github.com/golang-jwt/jwt/v5"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// errInvalidCredentials 이메일이 없거나 비밀번호가 틀린 경우 같은 에러를 준다.
+var errInvalidCredentials = status.Error(codes.Unauthenticated, "invalid email or password")
+
 func (s *AccountService) Login(ctx context.Context, in *pb.LoginRequest) (*pb.LoginResponse, error) {
 	logger := s.logger.With("method", "Login", "email", in.Email)
 	logger.Info("Starting user login")
 
+	// 1. 로그인 시도 제한 (이메일/IP)
+	_, ip := s.clientInfo(ctx)
+	emailScope, ipScope := emailThrottleScope(in.Email), ipThrottleScope(ip)
+	if err := s.checkLoginThrottle(ctx, emailScope, ipScope); err != nil {
+		logger.Warn("Login rejected by throttle", slog.String("ip", ip), slog.String("error", err.Error()))
+		return nil, err
+	}
+
 	db := s.pg.GetDB()
 	querier := postgresql.New(db)
 
@@ -44,115 +51,124 @@ func (s *AccountService) Login(ctx context.Context, in *pb.LoginRequest) (*pb.Lo
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Warn("User not found", slog.String("email", in.Email))
-			return nil, status.Errorf(codes.NotFound, "user not found")
+			// 가입된 사용자와 같은 시간이 걸리도록 더미 해시와 비교한다.
+			s.passwords.VerifyDummy(in.Password)
+			s.recordLoginFailureOrLog(ctx, logger, emailScope, ipScope)
+			return nil, errInvalidCredentials
 		}
 		logger.Error("Failed to get user", slog.String("error", err.Error()))
 		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
 	}
 	logger.Debug("User found", slog.String("user_id", user.ID.String()))
+	userScope := userThrottleScope(user.ID)
+	if err := s.checkLoginThrottle(ctx, userScope); err != nil {
+		logger.Warn("Login rejected by throttle", slog.String("user_id", user.ID.String()), slog.String("error", err.Error()))
+		return nil, err
+	}
 
 	// 2. 비밀번호 검증
 	logger.Debug("Verifying password")
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(in.Password)); err != nil {
+	ok, needsRehash, err := s.passwords.Verify(in.Password, user.PasswordHash)
+	if err != nil {
+		logger.Error("Failed to verify password",
+			slog.String("user_id", user.ID.String()),
+			slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to verify password: %v", err)
+	}
+	if !ok {
 		logger.Warn("Invalid password attempt", slog.String("user_id", user.ID.String()))
-		return nil, status.Errorf(codes.Unauthenticated, "invalid password")
+		s.recordLoginFailureOrLog(ctx, logger, emailScope, userScope, ipScope)
+		return nil, errInvalidCredentials
 	}
 	logger.Debug("Password verified successfully")
 
-	// 3. 액세스 토큰 생성
-	logger.Debug("Generating access token")
-	accessToken, err := s.generateAccessToken(user.ID)
-	if err != nil {
-		logger.Error("Failed to generate access token",
-			slog.String("user_id", user.ID.String()),
-			slog.String("error", err.Error()))
-		return nil, status.Errorf(codes.Internal, "failed to generate access token: %v", err)
+	// bcrypt 또는 약한 파라미터의 해시는 현재 설정으로 다시 저장한다.
+	// 실패해도 로그인 트랜잭션이 중단되지 않도록 트랜잭션 밖에서 처리한다.
+	if needsRehash {
+		s.rehashPassword(ctx, querier, logger, user.ID, in.Password)
 	}
 
-	// Redis에 저장
-	logger.Debug("Storing access token in Redis")
-	if err := s.RedisClient.RedisClient.Set(ctx, fmt.Sprintf("access_token:%s", user.ID.String()), accessToken, 15*time.Minute).Err(); err != nil {
-		logger.Error("Failed to store access token in Redis",
-			slog.String("user_id", user.ID.String()),
-			slog.String("error", err.Error()))
-		return nil, status.Errorf(codes.Internal, "failed to store access token: %v", err)
+	emailVerified := user.EmailVerifiedAt.Valid
+	if !emailVerified && s.config.Auth.UnverifiedLogin == unverifiedLoginReject {
+		logger.Warn("Login rejected for unverified email", slog.String("user_id", user.ID.String()))
+		return nil, status.Errorf(codes.FailedPrecondition, "email not verified")
+	}
+
+	// 2단계 인증이 켜진 계정은 토큰 대신 챌린지를 반환한다.
+	// 실패 횟수는 VerifyMFA 가 성공할 때 지워 비밀번호만으로 코드 추측 제한을 초기화할 수 없게 한다.
+	mfaEnabled, err := s.mfaEnabled(ctx, qtx, user.ID)
+	if err != nil {
+		logger.Error("Failed to check MFA", slog.String("user_id", user.ID.String()), slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to check mfa: %v", err)
+	}
+	if mfaEnabled {
+		mfaToken, err := s.createMFAChallenge(ctx, user.ID, in.DeviceName)
+		if err != nil {
+			logger.Error("Failed to create MFA challenge", slog.String("user_id", user.ID.String()), slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to create mfa challenge: %v", err)
+		}
+		logger.Info("MFA required", slog.String("user_id", user.ID.String()))
+		return &pb.LoginResponse{
+			UserId:        user.ID.String(),
+			EmailVerified: emailVerified,
+			MfaRequired:   true,
+			MfaToken:      mfaToken,
+		}, nil
+	}
+	if err := s.clearLoginFailures(ctx, emailScope, userScope); err != nil {
+		logger.Warn("Failed to clear login failures", slog.String("error", err.Error()))
 	}
 
-	// 4. 리프레시 토큰 생성
-	logger.Debug("Generating refresh token")
-	refreshToken, err := s.generateRefreshToken(user.ID)
+	// 3. 세션 생성
+	logger.Debug("Creating session", slog.String("device_name", in.DeviceName))
+	session, err := s.createSession(ctx, qtx, user.ID, in.DeviceName)
 	if err != nil {
-		logger.Error("Failed to generate refresh token",
+		logger.Error("Failed to create session",
 			slog.String("user_id", user.ID.String()),
 			slog.String("error", err.Error()))
-		return nil, status.Errorf(codes.Internal, "failed to generate refresh token: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create session: %v", err)
 	}
 
-	// DB에 저장
-	logger.Debug("Storing refresh token in database")
-	refreshTokenID := uuid.New()
-	expiresAt := time.Now().Add(14 * 24 * time.Hour)
-	if err := qtx.InsertRefreshToken(ctx, postgresql.InsertRefreshTokenParams{
-		ID:        refreshTokenID,
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: expiresAt,
-	}); err != nil {
-		logger.Error("Failed to store refresh token",
+	// 4. 토큰 발급
+	logger.Debug("Issuing tokens")
+	tokens, err := s.issueTokens(ctx, qtx, session, uuid.Nil)
+	if err != nil {
+		logger.Error("Failed to issue tokens",
 			slog.String("user_id", user.ID.String()),
-			slog.String("refresh_token_id", refreshTokenID.String()),
 			slog.String("error", err.Error()))
-		return nil, status.Errorf(codes.Internal, "failed to store refresh token: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
 	}
 
-	logger.Info("User login successful", slog.String("user_id", user.ID.String()))
+	logger.Info("User login successful",
+		slog.String("user_id", user.ID.String()),
+		slog.String("session_id", session.ID.String()))
 
 	// 5. 응답 반환
 	return &pb.LoginResponse{
-		UserId:       user.ID.String(),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		UserId:        user.ID.String(),
+		AccessToken:   tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+		EmailVerified: emailVerified,
 	}, nil
 }
 
-func (s *AccountService) generateAccessToken(userID uuid.UUID) (string, error) {
-	logger := s.logger.With("method", "generateAccessToken", "user_id", userID.String())
-	logger.Debug("Generating access token")
-
-	claims := jwt.RegisteredClaims{
-		Subject:   userID.String(),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	signedToken, err := token.SignedString([]byte(s.config.Auth.JWTSecret))
+// rehashPassword 로그인에 성공한 평문 비밀번호로 해시를 현재 설정에 맞춰 다시 저장한다.
+func (s *AccountService) rehashPassword(ctx context.Context, querier *postgresql.Queries, logger *slog.Logger, userID uuid.UUID, password string) {
+	passwordHash, err := s.hashPassword(password)
 	if err != nil {
-		logger.Error("Failed to sign access token", slog.String("error", err.Error()))
-		return "", err
-	}
-
-	logger.Debug("Access token generated successfully")
-	return signedToken, nil
-}
-
-func (s *AccountService) generateRefreshToken(userID uuid.UUID) (string, error) {
-	logger := s.logger.With("method", "generateRefreshToken", "user_id", userID.String())
-	logger.Debug("Generating refresh token")
-
-	claims := jwt.RegisteredClaims{
-		Subject:   userID.String(),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(14 * 24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		logger.Warn("Failed to rehash password",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		return
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	signedToken, err := token.SignedString([]byte(s.config.Auth.JWTSecret))
-	if err != nil {
-		logger.Error("Failed to sign refresh token", slog.String("error", err.Error()))
-		return "", err
+	if err := querier.UpdateUserPassword(ctx, postgresql.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		logger.Warn("Failed to store rehashed password",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		return
 	}
-
-	logger.Debug("Refresh token generated successfully")
-	return signedToken, nil
+	logger.Info("Password rehashed with current parameters", slog.String("user_id", userID.String()))
 }
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginDoesNotRevealAccountExistence(t *testing.T) {
+	s, db, fr := newTestService(t)
+	passwordUser(t, s, db, "correct horse")
+	ctx := context.Background()
+
+	_, unknownErr := s.Login(ctx, &pb.LoginRequest{Email: "nobody@example.com", Password: "correct horse"})
+	_, wrongErr := s.Login(ctx, &pb.LoginRequest{Email: "user@example.com", Password: "wrong horse"})
+	if unknownErr == nil || wrongErr == nil {
+		t.Fatalf("login succeeded: unknown=%v wrong=%v", unknownErr, wrongErr)
+	}
+	// 없는 이메일과 틀린 비밀번호는 코드와 메시지가 모두 같아야 한다.
+	unknown, wrong := status.Convert(unknownErr), status.Convert(wrongErr)
+	if unknown.Code() != wrong.Code() || unknown.Message() != wrong.Message() {
+		t.Errorf("unknown email = %v, wrong password = %v", unknown, wrong)
+	}
+	// 없는 이메일도 시도 제한에 같이 집계된다.
+	for _, email := range []string{"nobody@example.com", "user@example.com"} {
+		if failures, _ := fr.value("login_fail:" + emailThrottleScope(email)); failures != "1" {
+			t.Errorf("%s failures = %q, want 1", email, failures)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// 로그인 실패 제한 대상. Redis 키는 login_fail:<scope> (실패 횟수), login_block:<scope> (대기/잠금) 이다.
+func emailThrottleScope(email string) string {
+	return "email:" + auth.HashToken(strings.ToLower(email))
+}
+
+func userThrottleScope(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+func ipThrottleScope(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return "ip:" + ip
+}
+
+// checkLoginThrottle 대상 중 하나라도 대기/잠금 중이면 남은 시간을 담은 ResourceExhausted 를 반환한다.
+func (s *AccountService) checkLoginThrottle(ctx context.Context, scopes ...string) error {
+	var retryAfter time.Duration
+	for _, scope := range scopes {
+		if scope == "" {
+			continue
+		}
+		ttl, err := s.RedisClient.RedisClient.PTTL(ctx, "login_block:"+scope).Result()
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to check login throttle: %v", err)
+		}
+		if ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+	if retryAfter <= 0 {
+		return nil
+	}
+	return loginThrottledError(retryAfter)
+}
+
+// recordLoginFailure 대상별 실패 횟수를 늘리고, 횟수에 따라 지수 대기 또는 잠금을 건다.
+func (s *AccountService) recordLoginFailure(ctx context.Context, logger *slog.Logger, scopes ...string) error {
+	cfg := s.config.Auth.LoginThrottle
+	rdb := s.RedisClient.RedisClient
+	for _, scope := range scopes {
+		if scope == "" {
+			continue
+		}
+		failKey := "login_fail:" + scope
+		pipe := rdb.TxPipeline()
+		incr := pipe.Incr(ctx, failKey)
+		pipe.Expire(ctx, failKey, cfg.Window)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("record login failure: %w", err)
+		}
+		failures := int(incr.Val())
+
+		threshold := cfg.LockoutThreshold
+		if strings.HasPrefix(scope, "ip:") {
+			threshold = cfg.IPLockoutThreshold
+		}
+		var block time.Duration
+		switch {
+		case threshold > 0 && failures >= threshold:
+			block = cfg.LockoutDuration
+			logger.Warn("Login locked out", slog.String("scope", scope), slog.Int("failures", failures))
+		case failures > cfg.FreeAttempts:
+			block = backoffDelay(cfg.BaseDelay, cfg.MaxDelay, failures-cfg.FreeAttempts-1)
+		}
+		if block <= 0 {
+			continue
+		}
+		if err := rdb.Set(ctx, "login_block:"+scope, "1", block).Err(); err != nil {
+			return fmt.Errorf("set login block: %w", err)
+		}
+	}
+	return nil
+}
+
+// clearLoginFailures 대상의 실패 횟수와 대기/잠금을 지운다.
+func (s *AccountService) clearLoginFailures(ctx context.Context, scopes ...string) error {
+	var keys []string
+	for _, scope := range scopes {
+		if scope == "" {
+			continue
+		}
+		keys = append(keys, "login_fail:"+scope, "login_block:"+scope)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.RedisClient.RedisClient.Del(ctx, keys...).Err()
+}
+
+// backoffDelay base * 2^exp, max 를 넘지 않는다.
+func backoffDelay(base, max time.Duration, exp int) time.Duration {
+	delay := base
+	for i := 0; i < exp && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// loginThrottledError 재시도까지 남은 시간을 RetryInfo 로 담은 ResourceExhausted
+func loginThrottledError(retryAfter time.Duration) error {
+	// 초 단위로 올림해 클라이언트가 너무 일찍 재시도하지 않게 한다.
+	retryAfter = retryAfter.Truncate(time.Second) + time.Second
+	st, err := status.New(codes.ResourceExhausted, "too many failed login attempts, try again later").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return status.Errorf(codes.ResourceExhausted, "too many failed login attempts, try again in %s", retryAfter)
+	}
+	return st.Err()
+}
+
+// UnlockUser 로그인 실패로 걸린 사용자/이메일 대기와 잠금을 해제한다.
+func (s *AccountService) UnlockUser(ctx context.Context, in *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	logger := s.logger.With("method", "UnlockUser", "target_user_id", in.UserId)
+
+	p, err := s.requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Unlocking user")
+
+	userID, err := uuid.Parse(in.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id")
+	}
+	user, err := postgresql.New(s.pg.GetDB()).GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+
+	if err := s.clearLoginFailures(ctx, userThrottleScope(user.ID), emailThrottleScope(user.Email)); err != nil {
+		logger.Error("Failed to clear login failures", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to unlock user: %v", err)
+	}
+	logger.Info("User unlocked")
+	return &pb.UnlockUserResponse{}, nil
+}
+
+// recordLoginFailureOrLog recordLoginFailure 실패는 로그만 남긴다. 로그인 응답은 이미 실패로 정해져 있다.
+func (s *AccountService) recordLoginFailureOrLog(ctx context.Context, logger *slog.Logger, scopes ...string) {
+	if err := s.recordLoginFailure(ctx, logger, scopes...); err != nil {
+		logger.Error("Failed to record login failure", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		base, max time.Duration
+		exp       int
+		want      time.Duration
+	}{
+		{time.Second, 5 * time.Minute, 0, time.Second},
+		{time.Second, 5 * time.Minute, 1, 2 * time.Second},
+		{time.Second, 5 * time.Minute, 3, 8 * time.Second},
+		{time.Second, 5 * time.Minute, 8, 256 * time.Second},
+		{time.Second, 5 * time.Minute, 9, 5 * time.Minute},
+		{time.Second, 5 * time.Minute, 1000, 5 * time.Minute},
+		{10 * time.Second, 5 * time.Second, 0, 5 * time.Second},
+		{time.Second, time.Second, 4, time.Second},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(tt.base, tt.max, tt.exp); got != tt.want {
+			t.Errorf("backoffDelay(%s, %s, %d) = %s, want %s", tt.base, tt.max, tt.exp, got, tt.want)
+		}
+	}
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	// free_attempts 3, base_delay 1s, max_delay 5m, lockout_threshold 10, ip_lockout_threshold 100, lockout_duration 30m
+	tests := []struct {
+		name     string
+		scope    string
+		failures int
+		block    time.Duration // 0 이면 대기 없음
+	}{
+		{"within free attempts", userThrottleScope(uuid.New()), 3, 0},
+		{"first delay", userThrottleScope(uuid.New()), 4, time.Second},
+		{"doubling delay", userThrottleScope(uuid.New()), 6, 4 * time.Second},
+		{"last delay before lockout", userThrottleScope(uuid.New()), 9, 32 * time.Second},
+		{"lockout", emailThrottleScope("user@example.com"), 10, 30 * time.Minute},
+		{"ip capped delay", ipThrottleScope("192.0.2.1"), 50, 5 * time.Minute},
+		{"ip lockout", ipThrottleScope("192.0.2.1"), 100, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, fr := newTestService(t)
+			ctx := context.Background()
+			for i := 0; i < tt.failures; i++ {
+				if err := s.recordLoginFailure(ctx, s.logger, tt.scope); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := s.checkLoginThrottle(ctx, tt.scope)
+			if tt.block == 0 {
+				if err != nil {
+					t.Fatalf("checkLoginThrottle: %v, want nil", err)
+				}
+				return
+			}
+			if status.Code(err) != codes.ResourceExhausted {
+				t.Fatalf("checkLoginThrottle: %v, want ResourceExhausted", err)
+			}
+			if ttl := fr.ttl("login_block:" + tt.scope); ttl <= tt.block-time.Second || ttl > tt.block {
+				t.Errorf("block ttl = %s, want %s", ttl, tt.block)
+			}
+			var retry *errdetails.RetryInfo
+			for _, d := range status.Convert(err).Details() {
+				if r, ok := d.(*errdetails.RetryInfo); ok {
+					retry = r
+				}
+			}
+			if retry == nil || retry.RetryDelay.AsDuration() < tt.block-time.Second || retry.RetryDelay.AsDuration() > tt.block+time.Second {
+				t.Errorf("retry info = %v, want about %s", retry, tt.block)
+			}
+
+			// 성공한 로그인은 실패 횟수와 대기를 지운다.
+			if err := s.clearLoginFailures(ctx, tt.scope); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.checkLoginThrottle(ctx, tt.scope); err != nil {
+				t.Errorf("checkLoginThrottle after clear: %v", err)
+			}
+		})
+	}
+}
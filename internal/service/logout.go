@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Logout 현재 세션을 종료한다.
+// 세션과 리프레시 토큰 폐기, 액세스 토큰 jti 거부 목록 등록 순으로 처리하고 커밋한 뒤 Redis 세션을 지운다.
+func (s *AccountService) Logout(ctx context.Context, in *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	logger := s.logger.With("method", "Logout")
+	logger.Info("Starting user logout")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		logger.Warn("Unauthenticated logout request", slog.String("error", err.Error()))
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String(), "session_id", p.SessionID.String())
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	var endedSessions []uuid.UUID
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			if commitErr := tx.Commit(); commitErr != nil {
+				logger.Error("Failed to commit transaction", slog.String("error", commitErr.Error()))
+				return
+			}
+			s.uncacheSessions(ctx, logger, endedSessions)
+		}
+	}()
+
+	// 1. 세션 및 세션의 리프레시 토큰 폐기. Redis 세션은 커밋한 뒤 지운다.
+	logger.Debug("Ending session")
+	if _, err = s.endSession(ctx, qtx, p.UserID, p.SessionID); err != nil {
+		logger.Error("Failed to end session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to end session: %v", err)
+	}
+	endedSessions = []uuid.UUID{p.SessionID}
+
+	// 2. 전달된 리프레시 토큰 폐기 (세션 도입 이전 토큰 대비)
+	if in.RefreshToken != "" {
+		logger.Debug("Revoking refresh token")
+		if err = qtx.RevokeRefreshToken(ctx, postgresql.RevokeRefreshTokenParams{
+			TokenHash: auth.HashToken(in.RefreshToken),
+			UserID:    p.UserID,
+		}); err != nil {
+			logger.Error("Failed to revoke refresh token", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to revoke refresh token: %v", err)
+		}
+	}
+
+	// 3. 액세스 토큰 jti 거부 목록 등록
+	logger.Debug("Adding access token to denylist", slog.String("jti", p.TokenID))
+	if err = s.revokeAccessToken(ctx, p.TokenID, p.ExpiresAt); err != nil {
+		logger.Error("Failed to revoke access token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to revoke access token: %v", err)
+	}
+
+	logger.Info("User logout successful")
+	return &pb.LogoutResponse{}, nil
+}
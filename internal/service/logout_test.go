@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// signIn userID 의 세션을 Redis 에 올리고, 그 세션의 액세스 토큰을 authorization 메타데이터로 담은 context 를 만든다.
+func signIn(t *testing.T, s *AccountService, userID uuid.UUID, roles ...string) (context.Context, *postgresql.AccountSession, string) {
+	t.Helper()
+	now := time.Now()
+	session := &postgresql.AccountSession{
+		ID:         uuid.New(),
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+	if err := s.cacheSession(context.Background(), session); err != nil {
+		t.Fatalf("cacheSession: %v", err)
+	}
+	token, err := s.generateAccessToken(userID, session.ID, true, roles)
+	if err != nil {
+		t.Fatalf("generateAccessToken: %v", err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	return ctx, session, token
+}
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	s, db, fr := newTestService(t)
+	ctx, session, token := signIn(t, s, uuid.New(), roleCustomer)
+	claims, err := s.parseAccessToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.handle("RevokeSession", func(args []driver.Value) ([][]driver.Value, error) {
+		if args[0] != session.ID.String() || args[1] != session.UserID.String() {
+			t.Errorf("RevokeSession args = %v", args)
+		}
+		return affected(1), nil
+	})
+	db.handle("RevokeSessionRefreshTokens", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	refreshToken := "refresh-token"
+	db.handle("RevokeRefreshToken", func(args []driver.Value) ([][]driver.Value, error) {
+		if args[0] != auth.HashToken(refreshToken) {
+			t.Errorf("RevokeRefreshToken hash = %v", args[0])
+		}
+		return affected(1), nil
+	})
+
+	if _, err := s.Logout(ctx, &pb.LogoutRequest{RefreshToken: refreshToken}); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if db.called("RevokeSession") != 1 || db.called("RevokeSessionRefreshTokens") != 1 || db.called("RevokeRefreshToken") != 1 {
+		t.Error("session or refresh tokens were not revoked")
+	}
+	if _, ok := fr.value(sessionKey(session.ID)); ok {
+		t.Error("session is still cached in Redis")
+	}
+	// jti 는 액세스 토큰이 만료될 때까지만 거부 목록에 남는다.
+	if ttl := fr.ttl("revoked_jti:" + claims.ID); ttl <= 0 || ttl > s.config.Auth.AccessTokenTTL {
+		t.Errorf("denylist ttl = %v, want (0, %v]", ttl, s.config.Auth.AccessTokenTTL)
+	}
+	if _, err := s.verifyAccessToken(context.Background(), token); !errors.Is(err, errAccessTokenRevoked) {
+		t.Errorf("verifyAccessToken after logout: got %v, want %v", err, errAccessTokenRevoked)
+	}
+	if _, err := s.Logout(ctx, &pb.LogoutRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("second logout: got %v, want Unauthenticated", err)
+	}
+}
+
+func TestLogoutWithoutToken(t *testing.T) {
+	s, db, _ := newTestService(t)
+	if _, err := s.Logout(context.Background(), &pb.LogoutRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got %v, want Unauthenticated", err)
+	}
+	if db.called("RevokeSession") != 0 {
+		t.Error("session was revoked without a token")
+	}
+}
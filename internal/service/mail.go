@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	"github.com/escape-ship/accountsrv/internal/outbox"
+)
+
+// enqueueMail 메일을 아웃박스에 쌓는다. qtx 트랜잭션이 커밋되어야 발송된다.
+// 언어는 요청의 Accept-Language 를 따른다.
+func (s *AccountService) enqueueMail(ctx context.Context, qtx *postgresql.Queries, kind mailer.Kind, to string, data map[string]string) error {
+	if to == "" {
+		// 이메일 없이 가입한 소셜 로그인 사용자
+		return nil
+	}
+	return outbox.Enqueue(ctx, qtx, outbox.Mail{
+		Kind:   kind,
+		To:     to,
+		Locale: mailer.NormalizeLocale(acceptLanguage(ctx), s.config.Mail.DefaultLocale),
+		Data:   data,
+	})
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+var errMissingBearerToken = errors.New("missing bearer token")
+
+// bearerToken authorization 메타데이터에서 Bearer 토큰을 꺼낸다.
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", errMissingBearerToken
+	}
+	for _, v := range md.Get("authorization") {
+		if scheme, token, found := strings.Cut(v, " "); found && strings.EqualFold(scheme, "bearer") && token != "" {
+			return token, nil
+		}
+	}
+	return "", errMissingBearerToken
+}
+
+// clientInfo 요청의 User-Agent 와 클라이언트 IP 를 반환한다.
+// IP 는 연결한 상대(peer) 주소이다. 상대가 신뢰하는 프록시(app.trusted_proxies)일 때만 x-forwarded-for 를 보고,
+// 오른쪽부터 신뢰하는 프록시가 아닌 첫 주소를 쓴다. 왼쪽 값은 클라이언트가 마음대로 넣을 수 있다.
+func (s *AccountService) clientInfo(ctx context.Context) (userAgent, ip string) {
+	var forwarded []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		userAgent = firstMetadata(md, "grpcgateway-user-agent", "user-agent")
+		forwarded = md.Get("x-forwarded-for")
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	return userAgent, forwardedClientIP(s.trustedProxies, ip, forwarded)
+}
+
+// forwardedClientIP 상대 주소 peerIP 와 x-forwarded-for 값들로 클라이언트 IP 를 정한다.
+func forwardedClientIP(trusted []netip.Prefix, peerIP string, forwarded []string) string {
+	if !isTrustedProxy(trusted, peerIP) {
+		return peerIP
+	}
+	var hops []string
+	for _, v := range forwarded {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		addr, err := netip.ParseAddr(hop)
+		if err != nil {
+			// 형식이 틀린 값 왼쪽은 믿을 수 없다.
+			break
+		}
+		if !isTrustedProxy(trusted, hop) {
+			return addr.Unmap().String()
+		}
+	}
+	return peerIP
+}
+
+func isTrustedProxy(trusted []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptLanguage 요청의 Accept-Language. grpc-gateway 를 거친 요청은 게이트웨이가 넘겨준 값을 쓴다.
+func acceptLanguage(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		return firstMetadata(md, "grpcgateway-accept-language", "accept-language")
+	}
+	return ""
+}
+
+func firstMetadata(md metadata.MD, keys ...string) string {
+	for _, key := range keys {
+		if v := md.Get(key); len(v) > 0 && v[0] != "" {
+			return v[0]
+		}
+	}
+	return ""
+}
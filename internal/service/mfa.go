@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errTOTPNotConfigured = errors.New("totp secret encryption key is not configured")
+
+// errInvalidMFAToken 챌린지가 없거나 만료/사용된 경우
+var errInvalidMFAToken = status.Error(codes.Unauthenticated, "invalid or expired mfa token")
+
+// mfaChallenge 비밀번호 확인을 마치고 2단계 인증을 기다리는 로그인.
+// Redis 의 mfa_challenge:<sha256(token)> 해시에 저장한다.
+type mfaChallenge struct {
+	key        string
+	UserID     uuid.UUID
+	DeviceName string
+}
+
+// mfaEnabled 확인까지 마친 TOTP 가 있는지
+func (s *AccountService) mfaEnabled(ctx context.Context, qtx *postgresql.Queries, userID uuid.UUID) (bool, error) {
+	current, err := qtx.GetUserTOTP(ctx, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return current.ConfirmedAt.Valid, nil
+}
+
+// createMFAChallenge 2단계 인증용 일회용 토큰을 만든다. 유효시간은 auth.mfa_challenge_ttl 이다.
+func (s *AccountService) createMFAChallenge(ctx context.Context, userID uuid.UUID, deviceName string) (string, error) {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	key := "mfa_challenge:" + auth.HashToken(token)
+	pipe := s.RedisClient.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", userID.String(), "device_name", deviceName, "attempts", 0)
+	pipe.Expire(ctx, key, s.config.Auth.MFAChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("store mfa challenge: %w", err)
+	}
+	return token, nil
+}
+
+// getMFAChallenge 토큰에 해당하는 챌린지. 없으면 nil 이다.
+func (s *AccountService) getMFAChallenge(ctx context.Context, token string) (*mfaChallenge, error) {
+	key := "mfa_challenge:" + auth.HashToken(token)
+	fields, err := s.RedisClient.RedisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	userID, err := uuid.Parse(fields["user_id"])
+	if err != nil {
+		return nil, nil
+	}
+	return &mfaChallenge{key: key, UserID: userID, DeviceName: fields["device_name"]}, nil
+}
+
+// failMFAChallenge 실패 횟수를 늘리고 auth.mfa_max_attempts 에 도달하면 챌린지를 지운다.
+func (s *AccountService) failMFAChallenge(ctx context.Context, challenge *mfaChallenge) error {
+	rdb := s.RedisClient.RedisClient
+	attempts, err := rdb.HIncrBy(ctx, challenge.key, "attempts", 1).Result()
+	if err != nil {
+		return err
+	}
+	if attempts >= int64(s.config.Auth.MFAMaxAttempts) {
+		return rdb.Del(ctx, challenge.key).Err()
+	}
+	return nil
+}
+
+// consumeMFAChallenge 챌린지를 지운다. 동시에 같은 챌린지로 로그인을 마치지 못하도록 지운 쪽만 true 이다.
+func (s *AccountService) consumeMFAChallenge(ctx context.Context, challenge *mfaChallenge) (bool, error) {
+	n, err := s.RedisClient.RedisClient.Del(ctx, challenge.key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// VerifyMFA Login 이 반환한 mfa_token 과 TOTP 코드(또는 복구 코드)로 로그인을 마친다.
+func (s *AccountService) VerifyMFA(ctx context.Context, in *pb.VerifyMFARequest) (*pb.VerifyMFAResponse, error) {
+	logger := s.logger.With("method", "VerifyMFA")
+	logger.Info("Verifying second factor")
+
+	if in.MfaToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "mfa_token is required")
+	}
+	if in.Code == "" && in.RecoveryCode == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code or recovery_code is required")
+	}
+
+	// 1. 챌린지 확인
+	challenge, err := s.getMFAChallenge(ctx, in.MfaToken)
+	if err != nil {
+		logger.Error("Failed to get MFA challenge", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get mfa challenge: %v", err)
+	}
+	if challenge == nil {
+		logger.Warn("Unknown MFA token")
+		return nil, errInvalidMFAToken
+	}
+	logger = logger.With("user_id", challenge.UserID.String())
+
+	_, ip := s.clientInfo(ctx)
+	userScope, ipScope := userThrottleScope(challenge.UserID), ipThrottleScope(ip)
+	if err := s.checkLoginThrottle(ctx, userScope, ipScope); err != nil {
+		logger.Warn("MFA rejected by throttle", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	// 2. 코드 확인
+	current, err := qtx.GetUserTOTPForUpdate(ctx, challenge.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// 챌린지 발급 후 2단계 인증이 꺼졌다. 다시 로그인하게 한다.
+			logger.Warn("TOTP no longer enabled")
+			return nil, errInvalidMFAToken
+		}
+		logger.Error("Failed to get TOTP", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get totp: %v", err)
+	}
+	ok, err := s.verifySecondFactor(ctx, qtx, current, in.Code, in.RecoveryCode)
+	if err != nil {
+		logger.Error("Failed to verify second factor", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to verify code: %v", err)
+	}
+	if !ok {
+		logger.Warn("Invalid second factor code")
+		if err := s.failMFAChallenge(ctx, challenge); err != nil {
+			logger.Error("Failed to record MFA failure", slog.String("error", err.Error()))
+		}
+		s.recordLoginFailureOrLog(ctx, logger, userScope, ipScope)
+		return nil, status.Errorf(codes.Unauthenticated, "invalid code")
+	}
+	consumed, err := s.consumeMFAChallenge(ctx, challenge)
+	if err != nil {
+		logger.Error("Failed to consume MFA challenge", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to consume mfa challenge: %v", err)
+	}
+	if !consumed {
+		logger.Warn("MFA challenge already used")
+		err = errInvalidMFAToken
+		return nil, err
+	}
+
+	// 3. 세션 생성 및 토큰 발급
+	user, err := qtx.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	session, err := s.createSession(ctx, qtx, user.ID, challenge.DeviceName)
+	if err != nil {
+		logger.Error("Failed to create session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to create session: %v", err)
+	}
+	tokens, err := s.issueTokens(ctx, qtx, session, uuid.Nil)
+	if err != nil {
+		logger.Error("Failed to issue tokens", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
+	}
+	if err := s.clearLoginFailures(ctx, emailThrottleScope(user.Email), userScope); err != nil {
+		logger.Warn("Failed to clear login failures", slog.String("error", err.Error()))
+	}
+
+	logger.Info("MFA login successful",
+		slog.String("session_id", session.ID.String()),
+		slog.String("factor", mfaFactor(in)))
+	return &pb.VerifyMFAResponse{
+		UserId:        user.ID.String(),
+		AccessToken:   tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+	}, nil
+}
+
+// mfaFactor 로그용 2단계 인증 수단
+func mfaFactor(in *pb.VerifyMFARequest) string {
+	if in.Code != "" {
+		return "totp"
+	}
+	return "recovery_code"
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// oauthState 소셜 로그인 인가 요청 하나의 상태. 콜백에서 한 번만 꺼내 쓴다.
+type oauthState struct {
+	Provider     string    `json:"provider"`
+	CodeVerifier string    `json:"code_verifier"` // PKCE (RFC 7636)
+	Nonce        string    `json:"nonce"`
+	UserID       uuid.UUID `json:"user_id"`                // 계정 연결을 시작한 사용자. 로그인이면 uuid.Nil
+	BindingHash  string    `json:"binding_hash,omitempty"` // 로그인을 시작한 클라이언트가 가진 값의 sha256
+}
+
+// saveOAuthState state, PKCE verifier, nonce 를 만들어 저장한다.
+// userID 는 계정 연결을 시작한 사용자이고, 로그인이면 uuid.Nil 이다.
+// 로그인이면 state 를 시작한 클라이언트에 묶는 binding 도 만들어 해시만 저장하고 원문을 돌려준다.
+// 계정 연결은 state 가 이미 인증된 사용자에 묶여 있으므로 binding 이 빈 문자열이다.
+func (s *AccountService) saveOAuthState(ctx context.Context, provider string, userID uuid.UUID) (oauth.AuthRequest, string, error) {
+	var req oauth.AuthRequest
+	var binding string
+	values := []*string{&req.State, &req.CodeVerifier, &req.Nonce}
+	if userID == uuid.Nil {
+		values = append(values, &binding)
+	}
+	for _, v := range values {
+		token, err := auth.NewOpaqueToken()
+		if err != nil {
+			return oauth.AuthRequest{}, "", err
+		}
+		*v = token
+	}
+	stored := oauthState{Provider: provider, CodeVerifier: req.CodeVerifier, Nonce: req.Nonce, UserID: userID}
+	if binding != "" {
+		stored.BindingHash = auth.HashToken(binding)
+	}
+	value, err := json.Marshal(stored)
+	if err != nil {
+		return oauth.AuthRequest{}, "", err
+	}
+	key := "oauth_state:" + auth.HashToken(req.State)
+	if err := s.RedisClient.RedisClient.Set(ctx, key, value, s.config.Auth.OAuthStateTTL).Err(); err != nil {
+		return oauth.AuthRequest{}, "", fmt.Errorf("store oauth state: %w", err)
+	}
+	return req, binding, nil
+}
+
+// takeOAuthState state 를 꺼내면서 지운다 (한 번만 사용).
+// 없거나 제공자나 시작한 사용자가 다르면 nil 이다. 로그인 state 로 계정을 연결하거나 그 반대로 쓸 수 없다.
+// 로그인 state 는 saveOAuthState 가 돌려준 binding 까지 같아야 한다. 다른 브라우저로 넘어간 콜백은 거부된다.
+func (s *AccountService) takeOAuthState(ctx context.Context, provider string, userID uuid.UUID, state, binding string) (*oauth.AuthRequest, error) {
+	value, err := s.RedisClient.RedisClient.GetDel(ctx, "oauth_state:"+auth.HashToken(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored oauthState
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Provider != provider || stored.UserID != userID {
+		return nil, nil
+	}
+	if userID == uuid.Nil && subtle.ConstantTimeCompare([]byte(stored.BindingHash), []byte(auth.HashToken(binding))) != 1 {
+		return nil, nil
+	}
+	return &oauth.AuthRequest{
+		State:        state,
+		CodeVerifier: stored.CodeVerifier,
+		Nonce:        stored.Nonce,
+	}, nil
+}
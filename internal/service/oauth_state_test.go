@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	"github.com/google/uuid"
+)
+
+func TestTakeOAuthState(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name     string
+		saveUser uuid.UUID
+		provider string
+		takeUser uuid.UUID
+		ok       bool
+	}{
+		{"login", uuid.Nil, oauth.ProviderKakao, uuid.Nil, true},
+		{"link", userID, oauth.ProviderKakao, userID, true},
+		{"other provider", uuid.Nil, oauth.ProviderGoogle, uuid.Nil, false},
+		{"link state used for login", userID, oauth.ProviderKakao, uuid.Nil, false},
+		{"login state used for link", uuid.Nil, oauth.ProviderKakao, userID, false},
+		{"other user", userID, oauth.ProviderKakao, uuid.New(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, _ := newTestService(t)
+			ctx := context.Background()
+			saved, binding, err := s.saveOAuthState(ctx, oauth.ProviderKakao, tt.saveUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.takeOAuthState(ctx, tt.provider, tt.takeUser, saved.State, binding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got != nil) != tt.ok {
+				t.Fatalf("takeOAuthState = %+v, want ok %v", got, tt.ok)
+			}
+			if got != nil && (got.CodeVerifier != saved.CodeVerifier || got.Nonce != saved.Nonce) {
+				t.Errorf("takeOAuthState = %+v, want %+v", got, saved)
+			}
+
+			// 성공하든 실패하든 state 는 한 번만 꺼낼 수 있다.
+			again, err := s.takeOAuthState(ctx, oauth.ProviderKakao, tt.saveUser, saved.State, binding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != nil {
+				t.Error("state used twice")
+			}
+		})
+	}
+}
+
+func TestTakeOAuthStateExpired(t *testing.T) {
+	s, _, _ := newTestService(t)
+	s.config.Auth.OAuthStateTTL = 10 * time.Millisecond
+	ctx := context.Background()
+	saved, binding, err := s.saveOAuthState(ctx, oauth.ProviderKakao, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	got, err := s.takeOAuthState(ctx, oauth.ProviderKakao, uuid.Nil, saved.State, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Error("expired state accepted")
+	}
+}
+
+func TestSaveOAuthStateIsUnique(t *testing.T) {
+	s, _, _ := newTestService(t)
+	a, aBinding, err := s.saveOAuthState(context.Background(), oauth.ProviderKakao, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, bBinding, err := s.saveOAuthState(context.Background(), oauth.ProviderKakao, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.State == b.State || a.CodeVerifier == b.CodeVerifier || a.Nonce == b.Nonce || aBinding == bBinding {
+		t.Error("authorization requests share values")
+	}
+	if a.State == a.CodeVerifier || a.State == a.Nonce || a.State == aBinding {
+		t.Error("state reused as verifier, nonce or binding")
+	}
+}
+
+func TestTakeOAuthStateRequiresBinding(t *testing.T) {
+	s, _, fr := newTestService(t)
+	ctx := context.Background()
+	saved, binding, err := s.saveOAuthState(ctx, oauth.ProviderKakao, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binding == "" {
+		t.Fatal("login state has no binding")
+	}
+	// Redis 에는 binding 원문을 남기지 않는다.
+	stored, _ := fr.value("oauth_state:" + auth.HashToken(saved.State))
+	if strings.Contains(stored, binding) || !strings.Contains(stored, auth.HashToken(binding)) {
+		t.Errorf("stored state = %s", stored)
+	}
+
+	for _, other := range []string{"", "other-binding"} {
+		got, err := s.takeOAuthState(ctx, oauth.ProviderKakao, uuid.Nil, saved.State, other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Errorf("binding %q accepted", other)
+		}
+	}
+	// 틀린 binding 으로 시도해도 state 는 소모된다.
+	if got, _ := s.takeOAuthState(ctx, oauth.ProviderKakao, uuid.Nil, saved.State, binding); got != nil {
+		t.Error("state reused after a binding mismatch")
+	}
+}
+
+func TestLinkStateHasNoBinding(t *testing.T) {
+	s, _, _ := newTestService(t)
+	userID := uuid.New()
+	saved, binding, err := s.saveOAuthState(context.Background(), oauth.ProviderKakao, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binding != "" {
+		t.Errorf("link state binding = %q, want none", binding)
+	}
+	if got, _ := s.takeOAuthState(context.Background(), oauth.ProviderKakao, userID, saved.State, ""); got == nil {
+		t.Error("link state rejected")
+	}
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WebAuthn 세레모니 종류. Redis 의 webauthn_session:<sha256(session_id)> 에 함께 저장해 다른 용도로 쓰지 못하게 한다.
+const (
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+)
+
+// errInvalidPasskeySession 세션이 없거나 만료/사용된 경우
+var errInvalidPasskeySession = status.Error(codes.InvalidArgument, "invalid or expired passkey session")
+
+// passkeySession Begin 과 Finish 사이에 보관하는 세레모니 상태
+type passkeySession struct {
+	Ceremony string               `json:"ceremony"`
+	Data     webauthn.SessionData `json:"data"`
+}
+
+// webAuthnUser webauthn.User 구현. 사용자 핸들은 사용자 ID(UUID 16바이트)이다.
+type webAuthnUser struct {
+	id          uuid.UUID
+	email       string
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte                         { return u.id[:] }
+func (u *webAuthnUser) WebAuthnName() string                       { return u.name() }
+func (u *webAuthnUser) WebAuthnDisplayName() string                { return u.name() }
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+func (u *webAuthnUser) WebAuthnIcon() string                       { return "" }
+
+// name 인증기에 보여줄 이름. 이메일 없이 가입한 소셜 로그인 사용자는 사용자 ID 를 쓴다.
+func (u *webAuthnUser) name() string {
+	if u.email == "" {
+		return u.id.String()
+	}
+	return u.email
+}
+
+// webAuthn auth.webauthn 설정의 Relying Party.
+// 패스키는 검색 가능한(discoverable) 자격 증명과 사용자 확인(생체/PIN)을 요구한다.
+func (s *AccountService) webAuthn() (*webauthn.WebAuthn, error) {
+	cfg := s.config.Auth.WebAuthn
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: cfg.ChallengeTTL, TimeoutUVD: cfg.ChallengeTTL}
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+}
+
+// loadWebAuthnUser 사용자와 등록된 자격 증명
+func (s *AccountService) loadWebAuthnUser(ctx context.Context, querier *postgresql.Queries, userID uuid.UUID) (*webAuthnUser, error) {
+	user, err := querier.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := querier.ListWebAuthnCredentialsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u := &webAuthnUser{id: user.ID, email: user.Email}
+	for _, row := range rows {
+		var transports []protocol.AuthenticatorTransport
+		for _, t := range strings.Split(row.Transports, ",") {
+			if t != "" {
+				transports = append(transports, protocol.AuthenticatorTransport(t))
+			}
+		}
+		u.credentials = append(u.credentials, webauthn.Credential{
+			ID:              row.CredentialID,
+			PublicKey:       row.PublicKey,
+			AttestationType: row.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: row.BackupEligible,
+				BackupState:    row.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    row.Aaguid,
+				SignCount: uint32(row.SignCount),
+			},
+		})
+	}
+	return u, nil
+}
+
+// savePasskeySession 세레모니 상태를 저장하고 클라이언트에 넘길 세션 ID 를 반환한다.
+func (s *AccountService) savePasskeySession(ctx context.Context, ceremony string, data *webauthn.SessionData) (string, error) {
+	sessionID, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	value, err := json.Marshal(passkeySession{Ceremony: ceremony, Data: *data})
+	if err != nil {
+		return "", err
+	}
+	key := "webauthn_session:" + auth.HashToken(sessionID)
+	if err := s.RedisClient.RedisClient.Set(ctx, key, value, s.config.Auth.WebAuthn.ChallengeTTL).Err(); err != nil {
+		return "", fmt.Errorf("store webauthn session: %w", err)
+	}
+	return sessionID, nil
+}
+
+// takePasskeySession 세레모니 상태를 꺼내면서 지운다 (한 번만 사용). 없거나 종류가 다르면 nil 이다.
+func (s *AccountService) takePasskeySession(ctx context.Context, ceremony, sessionID string) (*webauthn.SessionData, error) {
+	value, err := s.RedisClient.RedisClient.GetDel(ctx, "webauthn_session:"+auth.HashToken(sessionID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var session passkeySession
+	if err := json.Unmarshal(value, &session); err != nil {
+		return nil, err
+	}
+	if session.Ceremony != ceremony {
+		return nil, nil
+	}
+	return &session.Data, nil
+}
+
+// BeginPasskeyRegistration 현재 사용자의 패스키 등록을 시작한다.
+func (s *AccountService) BeginPasskeyRegistration(ctx context.Context, in *pb.BeginPasskeyRegistrationRequest) (*pb.BeginPasskeyRegistrationResponse, error) {
+	logger := s.logger.With("method", "BeginPasskeyRegistration")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Beginning passkey registration")
+
+	wa, err := s.webAuthn()
+	if err != nil {
+		logger.Error("Invalid WebAuthn configuration", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.FailedPrecondition, "passkeys are not configured")
+	}
+	user, err := s.loadWebAuthnUser(ctx, postgresql.New(s.pg.GetDB()), p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		logger.Error("Failed to load user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to load user: %v", err)
+	}
+
+	// 이미 등록한 인증기로 중복 등록하지 않게 한다.
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, credential := range user.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+	creation, state, err := wa.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		logger.Error("Failed to begin registration", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin passkey registration: %v", err)
+	}
+	options, err := json.Marshal(creation)
+	if err != nil {
+		logger.Error("Failed to encode options", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to encode options: %v", err)
+	}
+	sessionID, err := s.savePasskeySession(ctx, ceremonyRegistration, state)
+	if err != nil {
+		logger.Error("Failed to store passkey session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store passkey session: %v", err)
+	}
+
+	return &pb.BeginPasskeyRegistrationResponse{
+		SessionId: sessionID,
+		Options:   string(options),
+	}, nil
+}
+
+// FinishPasskeyRegistration 인증기의 응답을 검증하고 공개키를 저장한다.
+func (s *AccountService) FinishPasskeyRegistration(ctx context.Context, in *pb.FinishPasskeyRegistrationRequest) (*pb.FinishPasskeyRegistrationResponse, error) {
+	logger := s.logger.With("method", "FinishPasskeyRegistration")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Finishing passkey registration")
+
+	if in.SessionId == "" || in.Credential == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "session_id and credential are required")
+	}
+
+	wa, err := s.webAuthn()
+	if err != nil {
+		logger.Error("Invalid WebAuthn configuration", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.FailedPrecondition, "passkeys are not configured")
+	}
+	state, err := s.takePasskeySession(ctx, ceremonyRegistration, in.SessionId)
+	if err != nil {
+		logger.Error("Failed to get passkey session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get passkey session: %v", err)
+	}
+	if state == nil {
+		return nil, errInvalidPasskeySession
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(strings.NewReader(in.Credential))
+	if err != nil {
+		logger.Warn("Invalid passkey credential", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.InvalidArgument, "invalid credential: %v", err)
+	}
+
+	querier := postgresql.New(s.pg.GetDB())
+	user, err := s.loadWebAuthnUser(ctx, querier, p.UserID)
+	if err != nil {
+		logger.Error("Failed to load user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to load user: %v", err)
+	}
+	// 세션을 시작한 사용자와 다르면 라이브러리가 거부한다.
+	credential, err := wa.CreateCredential(user, *state, parsed)
+	if err != nil {
+		logger.Warn("Passkey registration rejected", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.InvalidArgument, "passkey registration failed: %v", err)
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+	if err := querier.InsertWebAuthnCredential(ctx, postgresql.InsertWebAuthnCredentialParams{
+		ID:              uuid.New(),
+		UserID:          p.UserID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Aaguid:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		Transports:      strings.Join(transports, ","),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            in.Name,
+	}); err != nil {
+		logger.Error("Failed to store passkey", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store passkey: %v", err)
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(credential.ID)
+	logger.Info("Passkey registered", slog.String("credential_id", credentialID))
+	return &pb.FinishPasskeyRegistrationResponse{CredentialId: credentialID}, nil
+}
+
+// errPasskeyCloned 서명 카운터가 늘지 않은 인증기. 복제된 인증기일 수 있다.
+var errPasskeyCloned = errors.New("passkey sign counter did not increase")
+
+// validatePasskeyAssertion 로그인 세레모니 상태로 인증기의 서명을 검증한다.
+// 사용자는 인증기가 돌려준 사용자 핸들로 load 에서 찾고, 서명 카운터가 늘지 않았으면 errPasskeyCloned 를 반환한다.
+func validatePasskeyAssertion(wa *webauthn.WebAuthn, state webauthn.SessionData, credential string, load func(uuid.UUID) (*webAuthnUser, error)) (*webAuthnUser, *webauthn.Credential, error) {
+	// 라이브러리는 검색 가능한 로그인에서 챌린지 만료를 확인하지 않는다.
+	if !state.Expires.IsZero() && state.Expires.Before(time.Now()) {
+		return nil, nil, errors.New("passkey challenge expired")
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(strings.NewReader(credential))
+	if err != nil {
+		return nil, nil, err
+	}
+	var user *webAuthnUser
+	validated, err := wa.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err = load(userID)
+		return user, err
+	}, state, parsed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if validated.Authenticator.CloneWarning {
+		return nil, nil, errPasskeyCloned
+	}
+	return user, validated, nil
+}
+
+// verifyPasskeyAssertion 로그인 세레모니를 꺼내(한 번만 사용) 패스키 서명을 검증하고 사용 기록을 갱신한다.
+// 반환하는 에러는 gRPC status 이고, 검증에 실패하면 Unauthenticated 이다.
+func (s *AccountService) verifyPasskeyAssertion(ctx context.Context, qtx *postgresql.Queries, logger *slog.Logger, wa *webauthn.WebAuthn, sessionID, credential string) (*webAuthnUser, error) {
+	state, err := s.takePasskeySession(ctx, ceremonyLogin, sessionID)
+	if err != nil {
+		logger.Error("Failed to get passkey session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get passkey session: %v", err)
+	}
+	if state == nil {
+		return nil, errInvalidPasskeySession
+	}
+
+	user, validated, err := validatePasskeyAssertion(wa, *state, credential, func(userID uuid.UUID) (*webAuthnUser, error) {
+		return s.loadWebAuthnUser(ctx, qtx, userID)
+	})
+	if err != nil {
+		if errors.Is(err, errPasskeyCloned) {
+			logger.Warn("Passkey sign counter did not increase, possible cloned authenticator")
+		} else {
+			logger.Warn("Passkey assertion rejected", slog.String("error", err.Error()))
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "passkey authentication failed")
+	}
+	if err := qtx.UpdateWebAuthnCredentialUsage(ctx, postgresql.UpdateWebAuthnCredentialUsageParams{
+		CredentialID: validated.ID,
+		SignCount:    int64(validated.Authenticator.SignCount),
+		BackupState:  validated.Flags.BackupState,
+	}); err != nil {
+		logger.Error("Failed to update passkey usage", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to update passkey: %v", err)
+	}
+	return user, nil
+}
+
+// BeginPasskeyLogin 패스키 로그인을 시작한다. 이메일을 받지 않아 가입 여부가 드러나지 않는다.
+func (s *AccountService) BeginPasskeyLogin(ctx context.Context, in *pb.BeginPasskeyLoginRequest) (*pb.BeginPasskeyLoginResponse, error) {
+	logger := s.logger.With("method", "BeginPasskeyLogin")
+	logger.Info("Beginning passkey login")
+
+	wa, err := s.webAuthn()
+	if err != nil {
+		logger.Error("Invalid WebAuthn configuration", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.FailedPrecondition, "passkeys are not configured")
+	}
+	assertion, state, err := wa.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		logger.Error("Failed to begin login", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin passkey login: %v", err)
+	}
+	options, err := json.Marshal(assertion)
+	if err != nil {
+		logger.Error("Failed to encode options", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to encode options: %v", err)
+	}
+	sessionID, err := s.savePasskeySession(ctx, ceremonyLogin, state)
+	if err != nil {
+		logger.Error("Failed to store passkey session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store passkey session: %v", err)
+	}
+
+	return &pb.BeginPasskeyLoginResponse{
+		SessionId: sessionID,
+		Options:   string(options),
+	}, nil
+}
+
+// FinishPasskeyLogin 인증기의 서명을 검증하고 Login 과 같은 토큰을 발급한다.
+// 사용자 확인(생체/PIN)을 거친 패스키이므로 TOTP 2단계 인증은 요구하지 않는다.
+func (s *AccountService) FinishPasskeyLogin(ctx context.Context, in *pb.FinishPasskeyLoginRequest) (*pb.FinishPasskeyLoginResponse, error) {
+	logger := s.logger.With("method", "FinishPasskeyLogin")
+	logger.Info("Finishing passkey login")
+
+	if in.SessionId == "" || in.Credential == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "session_id and credential are required")
+	}
+
+	_, ip := s.clientInfo(ctx)
+	ipScope := ipThrottleScope(ip)
+	if err := s.checkLoginThrottle(ctx, ipScope); err != nil {
+		logger.Warn("Passkey login rejected by throttle", slog.String("ip", ip), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	wa, err := s.webAuthn()
+	if err != nil {
+		logger.Error("Invalid WebAuthn configuration", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.FailedPrecondition, "passkeys are not configured")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	// 1. 서명 검증
+	user, err := s.verifyPasskeyAssertion(ctx, qtx, logger, wa, in.SessionId, in.Credential)
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			s.recordLoginFailureOrLog(ctx, logger, ipScope)
+		}
+		return nil, err
+	}
+	logger = logger.With("user_id", user.id.String())
+
+	account, err := qtx.GetUserByID(ctx, user.id)
+	if err != nil {
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	emailVerified := account.EmailVerifiedAt.Valid
+	if account.Email != "" && !emailVerified && s.config.Auth.UnverifiedLogin == unverifiedLoginReject {
+		logger.Warn("Login rejected for unverified email")
+		return nil, status.Errorf(codes.FailedPrecondition, "email not verified")
+	}
+
+	// 2. 세션 생성 및 토큰 발급
+	session, err := s.createSession(ctx, qtx, user.id, in.DeviceName)
+	if err != nil {
+		logger.Error("Failed to create session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to create session: %v", err)
+	}
+	tokens, err := s.issueTokens(ctx, qtx, session, uuid.Nil)
+	if err != nil {
+		logger.Error("Failed to issue tokens", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
+	}
+	if err := s.clearLoginFailures(ctx, emailThrottleScope(account.Email), userThrottleScope(user.id)); err != nil {
+		logger.Warn("Failed to clear login failures", slog.String("error", err.Error()))
+	}
+
+	logger.Info("Passkey login successful", slog.String("session_id", session.ID.String()))
+	return &pb.FinishPasskeyLoginResponse{
+		UserId:        user.id.String(),
+		AccessToken:   tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+		EmailVerified: emailVerified,
+	}, nil
+}
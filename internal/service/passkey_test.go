@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const testOrigin = "http://localhost:3000"
+
+// softAuthenticator 검색 가능한 P-256 자격 증명 하나를 가진 소프트웨어 인증기
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	counter      uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 16)
+	rand.Read(credentialID)
+	return &softAuthenticator{key: key, credentialID: credentialID}
+}
+
+// optionsChallenge Begin 응답 options 의 challenge (base64url)
+func optionsChallenge(t *testing.T, options string) string {
+	t.Helper()
+	var parsed struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			User      struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal([]byte(options), &parsed); err != nil {
+		t.Fatalf("decode options: %v", err)
+	}
+	return parsed.PublicKey.Challenge
+}
+
+func clientData(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    testOrigin,
+	})
+	return data
+}
+
+// authData rpIdHash | flags | signCount | attestedCredentialData
+func (a *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte("localhost"))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	return append(data, attested...)
+}
+
+// create navigator.credentials.create() 응답 ("none" 증명)
+func (a *softAuthenticator) create(t *testing.T, options string) string {
+	t.Helper()
+	var parsed struct {
+		PublicKey struct {
+			User struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal([]byte(options), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	userHandle, err := base64.RawURLEncoding.DecodeString(parsed.PublicKey.User.ID)
+	if err != nil {
+		t.Fatalf("decode user handle: %v", err)
+	}
+	a.userHandle = userHandle
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attested := make([]byte, 16) // AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	// UP | UV | AT
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(0x45, attested),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a.response(t, map[string]string{
+		"clientDataJSON":    b64(clientData("webauthn.create", optionsChallenge(t, options))),
+		"attestationObject": b64(attestation),
+	})
+}
+
+// get navigator.credentials.get() 응답. 호출할 때마다 서명 카운터를 올린다.
+func (a *softAuthenticator) get(t *testing.T, options string) string {
+	t.Helper()
+	a.counter++
+	authData := a.authData(0x05, nil) // UP | UV
+	data := clientData("webauthn.get", optionsChallenge(t, options))
+	clientHash := sha256.Sum256(data)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a.response(t, map[string]string{
+		"clientDataJSON":    b64(data),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) response(t *testing.T, response map[string]string) string {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+// passkeyStore 사용자 한 명과 그 패스키를 담은 가짜 테이블
+type passkeyStore struct {
+	mu          sync.Mutex
+	user        uuid.UUID
+	credentials []postgresql.InsertWebAuthnCredentialParams
+	usageCalls  int
+}
+
+func newPasskeyStore(db *fakeDB) *passkeyStore {
+	store := &passkeyStore{user: uuid.New()}
+	db.handle("GetUserByID", func(args []driver.Value) ([][]driver.Value, error) {
+		if args[0] != store.user.String() {
+			return nil, nil
+		}
+		return [][]driver.Value{{store.user.String(), "user@example.com", "", time.Now()}}, nil
+	})
+	db.handle("ListWebAuthnCredentialsByUser", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		var rows [][]driver.Value
+		for _, c := range store.credentials {
+			rows = append(rows, []driver.Value{
+				c.ID.String(), c.UserID.String(), c.CredentialID, c.PublicKey, c.AttestationType, c.Aaguid,
+				c.SignCount, c.Transports, c.BackupEligible, c.BackupState, c.Name, time.Now(), nil,
+			})
+		}
+		return rows, nil
+	})
+	db.handle("InsertWebAuthnCredential", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.credentials = append(store.credentials, postgresql.InsertWebAuthnCredentialParams{
+			ID:              uuid.MustParse(args[0].(string)),
+			UserID:          uuid.MustParse(args[1].(string)),
+			CredentialID:    args[2].([]byte),
+			PublicKey:       args[3].([]byte),
+			AttestationType: args[4].(string),
+			Aaguid:          args[5].([]byte),
+			SignCount:       args[6].(int64),
+			Transports:      args[7].(string),
+			BackupEligible:  args[8].(bool),
+			BackupState:     args[9].(bool),
+			Name:            args[10].(string),
+		})
+		return affected(1), nil
+	})
+	db.handle("UpdateWebAuthnCredentialUsage", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.usageCalls++
+		for i, c := range store.credentials {
+			if string(c.CredentialID) == string(args[0].([]byte)) {
+				store.credentials[i].SignCount = args[1].(int64)
+				return affected(1), nil
+			}
+		}
+		return affected(0), nil
+	})
+	db.handle("InsertSession", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	db.handle("ListUserRoles", func(args []driver.Value) ([][]driver.Value, error) { return nil, nil })
+	db.handle("InsertRefreshToken", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	return store
+}
+
+func (store *passkeyStore) signCount() int64 {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.credentials[0].SignCount
+}
+
+// registerPasskey 로그인한 사용자로 인증기를 등록한다.
+func registerPasskey(t *testing.T, s *AccountService, store *passkeyStore, authenticator *softAuthenticator) {
+	t.Helper()
+	ctx := context.WithValue(context.Background(), principalKey{}, &principal{UserID: store.user, SessionID: uuid.New()})
+	begin, err := s.BeginPasskeyRegistration(ctx, &pb.BeginPasskeyRegistrationRequest{})
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration: %v", err)
+	}
+	if _, err := s.FinishPasskeyRegistration(ctx, &pb.FinishPasskeyRegistrationRequest{
+		SessionId:  begin.SessionId,
+		Credential: authenticator.create(t, begin.Options),
+		Name:       "test key",
+	}); err != nil {
+		t.Fatalf("FinishPasskeyRegistration: %v", err)
+	}
+}
+
+func beginPasskeyLogin(t *testing.T, s *AccountService) *pb.BeginPasskeyLoginResponse {
+	t.Helper()
+	begin, err := s.BeginPasskeyLogin(context.Background(), &pb.BeginPasskeyLoginRequest{})
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin: %v", err)
+	}
+	return begin
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newPasskeyStore(db)
+	authenticator := newSoftAuthenticator(t)
+
+	registerPasskey(t, s, store, authenticator)
+	if len(store.credentials) != 1 {
+		t.Fatalf("stored credentials = %d, want 1", len(store.credentials))
+	}
+	if got := store.credentials[0]; got.UserID != store.user || string(got.CredentialID) != string(authenticator.credentialID) {
+		t.Fatalf("stored credential = %+v", got)
+	}
+
+	begin := beginPasskeyLogin(t, s)
+	resp, err := s.FinishPasskeyLogin(context.Background(), &pb.FinishPasskeyLoginRequest{
+		SessionId:  begin.SessionId,
+		Credential: authenticator.get(t, begin.Options),
+	})
+	if err != nil {
+		t.Fatalf("FinishPasskeyLogin: %v", err)
+	}
+	if resp.UserId != store.user.String() {
+		t.Errorf("user id = %s, want %s", resp.UserId, store.user)
+	}
+	p, err := s.verifyAccessToken(context.Background(), resp.AccessToken)
+	if err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+	if p.UserID != store.user {
+		t.Errorf("access token subject = %s, want %s", p.UserID, store.user)
+	}
+	if got := store.signCount(); got != 1 {
+		t.Errorf("stored sign count = %d, want 1", got)
+	}
+}
+
+func TestPasskeyLoginRejectsReplay(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newPasskeyStore(db)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, s, store, authenticator)
+
+	begin := beginPasskeyLogin(t, s)
+	credential := authenticator.get(t, begin.Options)
+	req := &pb.FinishPasskeyLoginRequest{SessionId: begin.SessionId, Credential: credential}
+	if _, err := s.FinishPasskeyLogin(context.Background(), req); err != nil {
+		t.Fatalf("FinishPasskeyLogin: %v", err)
+	}
+
+	// 같은 세션은 한 번만 쓸 수 있다.
+	if _, err := s.FinishPasskeyLogin(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("replayed session: got %v, want InvalidArgument", err)
+	}
+	// 지난 응답을 새 챌린지에 보내도 서명한 챌린지가 달라 거부된다.
+	next := beginPasskeyLogin(t, s)
+	if _, err := s.FinishPasskeyLogin(context.Background(), &pb.FinishPasskeyLoginRequest{
+		SessionId:  next.SessionId,
+		Credential: credential,
+	}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("replayed assertion: got %v, want Unauthenticated", err)
+	}
+}
+
+func TestPasskeyLoginRejectsExpiredChallenge(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newPasskeyStore(db)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, s, store, authenticator)
+
+	wa, err := s.webAuthn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertion, state, err := wa.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	options, _ := json.Marshal(assertion)
+	state.Expires = time.Now().Add(-time.Second)
+
+	load := func(userID uuid.UUID) (*webAuthnUser, error) {
+		return s.loadWebAuthnUser(context.Background(), postgresql.New(db.GetDB()), userID)
+	}
+	if _, _, err := validatePasskeyAssertion(wa, *state, authenticator.get(t, string(options)), load); err == nil {
+		t.Fatal("expired challenge accepted")
+	}
+	state.Expires = time.Now().Add(time.Minute)
+	if _, _, err := validatePasskeyAssertion(wa, *state, authenticator.get(t, string(options)), load); err != nil {
+		t.Fatalf("unexpired challenge rejected: %v", err)
+	}
+}
+
+func TestPasskeyLoginRejectsCounterRegression(t *testing.T) {
+	s, db, fr := newTestService(t)
+	store := newPasskeyStore(db)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, s, store, authenticator)
+
+	for i := 0; i < 3; i++ {
+		begin := beginPasskeyLogin(t, s)
+		if _, err := s.FinishPasskeyLogin(context.Background(), &pb.FinishPasskeyLoginRequest{
+			SessionId:  begin.SessionId,
+			Credential: authenticator.get(t, begin.Options),
+		}); err != nil {
+			t.Fatalf("FinishPasskeyLogin: %v", err)
+		}
+	}
+
+	// 복제된 인증기는 이미 쓰인 카운터 값을 다시 보낸다.
+	tests := []struct {
+		name    string
+		counter uint32 // 인증기가 보낼 서명 카운터
+	}{
+		{"same counter", 3},
+		{"lower counter", 1},
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 443}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator.counter = tt.counter - 1
+			usage := store.usageCalls
+			begin := beginPasskeyLogin(t, s)
+			_, err := s.FinishPasskeyLogin(ctx, &pb.FinishPasskeyLoginRequest{
+				SessionId:  begin.SessionId,
+				Credential: authenticator.get(t, begin.Options),
+			})
+			if status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("got %v, want Unauthenticated", err)
+			}
+			if store.usageCalls != usage || store.signCount() != 3 {
+				t.Errorf("sign count updated after clone warning")
+			}
+		})
+	}
+	if failures, _ := fr.value("login_fail:ip:192.0.2.1"); failures != "2" {
+		t.Errorf("recorded ip failures = %q, want 2", failures)
+	}
+
+	wa, err := s.webAuthn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertion, state, err := wa.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	options, _ := json.Marshal(assertion)
+	authenticator.counter = 0
+	load := func(userID uuid.UUID) (*webAuthnUser, error) {
+		return s.loadWebAuthnUser(context.Background(), postgresql.New(db.GetDB()), userID)
+	}
+	if _, _, err := validatePasskeyAssertion(wa, *state, authenticator.get(t, string(options)), load); !errors.Is(err, errPasskeyCloned) {
+		t.Errorf("validatePasskeyAssertion: got %v, want errPasskeyCloned", err)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// minBannedWordLength 이보다 짧은 금지어(이메일 앞부분 포함)는 검사하지 않는다.
+const minBannedWordLength = 3
+
+// validatePassword 비밀번호 정책 검사 (password.policy).
+// 위반 항목은 모두 모아 field 에 대한 BadRequest 상세를 담은 InvalidArgument 로 반환한다.
+// email 의 @ 앞부분은 금지어로 취급한다.
+func (s *AccountService) validatePassword(field, password, email string) error {
+	policy := s.config.Password.Policy
+	var violations []*errdetails.BadRequest_FieldViolation
+	violate := func(format string, args ...interface{}) {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if len([]rune(password)) < policy.MinLength {
+		violate("password must be at least %d characters", policy.MinLength)
+	}
+	if policy.MaxLength > 0 && len(password) > policy.MaxLength {
+		violate("password must be at most %d bytes", policy.MaxLength)
+	}
+
+	lower := strings.ToLower(password)
+	banned := policy.BannedWords
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		banned = append(banned[:len(banned):len(banned)], local)
+	}
+	for _, word := range banned {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if len(word) < minBannedWordLength || !strings.Contains(lower, word) {
+			continue
+		}
+		violate("password must not contain %q", word)
+	}
+
+	breached, err := s.breached.Contains(password)
+	if err != nil {
+		s.logger.Error("Failed to check breached passwords", "error", err.Error())
+		return status.Errorf(codes.Internal, "failed to check password: %v", err)
+	}
+	if breached {
+		violate("password has appeared in a data breach")
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	st, err := status.New(codes.InvalidArgument, "password does not meet the policy").
+		WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "password does not meet the policy")
+	}
+	return st.Err()
+}
+
+// hashPassword 현재 설정의 argon2id 로 비밀번호 해시 생성
+func (s *AccountService) hashPassword(password string) (string, error) {
+	return s.passwords.Hash(password)
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// passwordResetRequestedMessage 이메일 존재 여부와 관계없이 같은 응답을 준다.
+const passwordResetRequestedMessage = "If the email is registered, a password reset link has been sent"
+
+// RequestPasswordReset 비밀번호 재설정 토큰을 만들어 메일로 보낸다.
+// 가입 여부가 드러나지 않도록 이메일이 없어도 같은 응답을 반환한다.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, in *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	logger := s.logger.With("method", "RequestPasswordReset")
+	logger.Info("Password reset requested")
+
+	if in.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+	res := &pb.RequestPasswordResetResponse{Message: passwordResetRequestedMessage}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	user, err := qtx.GetUserByEmail(ctx, in.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Info("Password reset requested for unknown email")
+			err = nil
+			return res, nil
+		}
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	logger = logger.With("user_id", user.ID.String())
+
+	token, expiresAt, err := s.createPasswordResetToken(ctx, qtx, user.ID)
+	if err != nil {
+		logger.Error("Failed to create password reset token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to create password reset token: %v", err)
+	}
+	if err = s.enqueueMail(ctx, qtx, mailer.KindPasswordReset, user.Email, map[string]string{
+		"token":      token,
+		"expires_at": expiresAt.Format(time.RFC3339),
+	}); err != nil {
+		logger.Error("Failed to queue password reset mail", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to queue password reset mail: %v", err)
+	}
+
+	logger.Info("Password reset token issued")
+	return res, nil
+}
+
+// createPasswordResetToken 사용하지 않은 기존 토큰을 무효화하고 새 재설정 토큰을 저장한다.
+func (s *AccountService) createPasswordResetToken(ctx context.Context, qtx *postgresql.Queries, userID uuid.UUID) (string, time.Time, error) {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(s.config.Auth.PasswordResetTTL)
+	if err := qtx.InvalidatePasswordResetTokens(ctx, userID); err != nil {
+		return "", time.Time{}, err
+	}
+	if err := qtx.InsertPasswordResetToken(ctx, postgresql.InsertPasswordResetTokenParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ConfirmPasswordReset 재설정 토큰을 확인하고 비밀번호를 바꾼다.
+// 토큰은 한 번만 쓸 수 있으며, 성공하면 사용자의 모든 세션과 리프레시 토큰을 폐기한다.
+func (s *AccountService) ConfirmPasswordReset(ctx context.Context, in *pb.ConfirmPasswordResetRequest) (*pb.ConfirmPasswordResetResponse, error) {
+	logger := s.logger.With("method", "ConfirmPasswordReset")
+	logger.Info("Confirming password reset")
+
+	if in.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	var endedSessions []uuid.UUID
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			if commitErr := tx.Commit(); commitErr != nil {
+				logger.Error("Failed to commit transaction", slog.String("error", commitErr.Error()))
+				return
+			}
+			s.uncacheSessions(ctx, logger, endedSessions)
+		}
+	}()
+
+	// 1. 토큰 확인
+	stored, err := qtx.GetPasswordResetTokenForUpdate(ctx, auth.HashToken(in.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Warn("Unknown password reset token")
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired reset token")
+		}
+		logger.Error("Failed to get password reset token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get reset token: %v", err)
+	}
+	logger = logger.With("user_id", stored.UserID.String())
+	if stored.UsedAt.Valid || time.Now().After(stored.ExpiresAt) {
+		logger.Warn("Password reset token already used or expired")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid or expired reset token")
+	}
+
+	user, err := qtx.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+
+	// 2. 비밀번호 변경 및 토큰 사용 처리
+	if err = s.validatePassword("new_password", in.NewPassword, user.Email); err != nil {
+		logger.Warn("New password rejected by policy")
+		return nil, err
+	}
+	passwordHash, err := s.hashPassword(in.NewPassword)
+	if err != nil {
+		logger.Error("Failed to hash password", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
+	}
+	if err = qtx.UpdateUserPassword(ctx, postgresql.UpdateUserPasswordParams{
+		ID:           stored.UserID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		logger.Error("Failed to update password", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to update password: %v", err)
+	}
+	if err = qtx.InvalidatePasswordResetTokens(ctx, stored.UserID); err != nil {
+		logger.Error("Failed to invalidate reset tokens", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to invalidate reset tokens: %v", err)
+	}
+
+	// 3. 모든 세션과 리프레시 토큰 폐기
+	if endedSessions, err = s.endUserSessions(ctx, qtx, stored.UserID, uuid.Nil); err != nil {
+		logger.Error("Failed to end sessions", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to end sessions: %v", err)
+	}
+
+	// 4. 변경 알림 메일
+	if err = s.enqueueMail(ctx, qtx, mailer.KindPasswordChanged, user.Email, nil); err != nil {
+		logger.Error("Failed to queue password changed mail", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to queue password changed mail: %v", err)
+	}
+
+	logger.Info("Password reset completed")
+	return &pb.ConfirmPasswordResetResponse{}, nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// oneTimeToken 메일로 보내는 일회용 토큰의 저장된 행
+type oneTimeToken struct {
+	userID    uuid.UUID
+	hash      string
+	expiresAt time.Time
+	used      bool
+}
+
+// queuedMail 아웃박스에 쌓인 메일
+type queuedMail struct {
+	kind mailer.Kind
+	to   string
+	data map[string]string
+}
+
+// oneTimeTokenStore 일회용 토큰 테이블과 아웃박스의 가짜 구현.
+// name 은 쿼리 이름의 가운데 부분 (PasswordReset, EmailVerification) 이다.
+type oneTimeTokenStore struct {
+	mu     sync.Mutex
+	tokens []*oneTimeToken
+	mails  []queuedMail
+}
+
+func newOneTimeTokenStore(t *testing.T, db *fakeDB, name string) *oneTimeTokenStore {
+	store := &oneTimeTokenStore{}
+	db.handle("Insert"+name+"Token", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.tokens = append(store.tokens, &oneTimeToken{
+			userID:    uuid.MustParse(args[1].(string)),
+			hash:      args[2].(string),
+			expiresAt: args[3].(time.Time),
+		})
+		return affected(1), nil
+	})
+	db.handle("Get"+name+"TokenForUpdate", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for _, token := range store.tokens {
+			if token.hash == args[0] {
+				var usedAt driver.Value
+				if token.used {
+					usedAt = time.Now()
+				}
+				return [][]driver.Value{{uuid.NewString(), token.userID.String(), token.expiresAt, usedAt}}, nil
+			}
+		}
+		return nil, nil
+	})
+	db.handle("Invalidate"+name+"Tokens", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		n := 0
+		for _, token := range store.tokens {
+			if token.userID.String() == args[0] && !token.used {
+				token.used = true
+				n++
+			}
+		}
+		return affected(n), nil
+	})
+	db.handle("InsertOutboxMessage", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		mail := queuedMail{kind: mailer.Kind(args[1].(string)), to: args[2].(string)}
+		if err := json.Unmarshal(args[4].([]byte), &mail.data); err != nil {
+			t.Errorf("outbox payload: %v", err)
+		}
+		store.mails = append(store.mails, mail)
+		return affected(1), nil
+	})
+	return store
+}
+
+// mailsOf kind 메일 목록
+func (store *oneTimeTokenStore) mailsOf(kind mailer.Kind) []queuedMail {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var mails []queuedMail
+	for _, mail := range store.mails {
+		if mail.kind == kind {
+			mails = append(mails, mail)
+		}
+	}
+	return mails
+}
+
+// passwordUser user@example.com 으로 가입한 사용자와 현재 비밀번호 해시를 돌려주는 조회 쿼리를 등록한다.
+func passwordUser(t *testing.T, s *AccountService, db *fakeDB, password string) (uuid.UUID, *string) {
+	t.Helper()
+	userID := uuid.New()
+	passwordHash, err := s.hashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	row := func() [][]driver.Value {
+		mu.Lock()
+		defer mu.Unlock()
+		return [][]driver.Value{{userID.String(), "user@example.com", passwordHash, time.Now()}}
+	}
+	db.handle("GetUserByEmail", func(args []driver.Value) ([][]driver.Value, error) {
+		if args[0] != "user@example.com" {
+			return nil, nil
+		}
+		return row(), nil
+	})
+	db.handle("GetUserByID", func(args []driver.Value) ([][]driver.Value, error) { return row(), nil })
+	db.handle("UpdateUserPassword", func(args []driver.Value) ([][]driver.Value, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		passwordHash = args[1].(string)
+		return affected(1), nil
+	})
+	return userID, &passwordHash
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	s, db, fr := newTestService(t)
+	userID, passwordHash := passwordUser(t, s, db, "old correct horse")
+	store := newOneTimeTokenStore(t, db, "PasswordReset")
+	_, first, _ := signIn(t, s, userID, roleCustomer)
+	_, second, _ := signIn(t, s, userID, roleCustomer)
+	db.handle("RevokeUserSessions", func(args []driver.Value) ([][]driver.Value, error) {
+		if args[1] != uuid.Nil.String() {
+			t.Errorf("reset kept session %v", args[1])
+		}
+		return [][]driver.Value{{first.ID.String()}, {second.ID.String()}}, nil
+	})
+	db.handle("RevokeUserRefreshTokens", func(args []driver.Value) ([][]driver.Value, error) { return affected(2), nil })
+	ctx := context.Background()
+
+	resp, err := s.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Email: "user@example.com"})
+	if err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	if resp.Message != passwordResetRequestedMessage {
+		t.Errorf("message = %q", resp.Message)
+	}
+	mails := store.mailsOf(mailer.KindPasswordReset)
+	if len(mails) != 1 || mails[0].to != "user@example.com" {
+		t.Fatalf("reset mails = %+v", mails)
+	}
+	token := mails[0].data["token"]
+	if len(store.tokens) != 1 || store.tokens[0].hash != auth.HashToken(token) {
+		t.Fatal("reset token is not stored by its hash")
+	}
+
+	if _, err := s.ConfirmPasswordReset(ctx, &pb.ConfirmPasswordResetRequest{Token: token, NewPassword: "new correct horse"}); err != nil {
+		t.Fatalf("ConfirmPasswordReset: %v", err)
+	}
+	if ok, _, _ := s.passwords.Verify("new correct horse", *passwordHash); !ok {
+		t.Error("password was not changed")
+	}
+	for _, id := range []uuid.UUID{first.ID, second.ID} {
+		if _, ok := fr.value(sessionKey(id)); ok {
+			t.Errorf("session %s survived the password reset", id)
+		}
+	}
+	if len(store.mailsOf(mailer.KindPasswordChanged)) != 1 {
+		t.Error("password changed mail was not queued")
+	}
+
+	// 같은 토큰은 두 번 쓸 수 없다.
+	_, err = s.ConfirmPasswordReset(ctx, &pb.ConfirmPasswordResetRequest{Token: token, NewPassword: "another correct horse"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("reused token: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestPasswordResetRequestInvalidatesOlderTokens(t *testing.T) {
+	s, db, _ := newTestService(t)
+	passwordUser(t, s, db, "old correct horse")
+	store := newOneTimeTokenStore(t, db, "PasswordReset")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Email: "user@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mails := store.mailsOf(mailer.KindPasswordReset)
+	if len(mails) != 2 {
+		t.Fatalf("got %d reset mails, want 2", len(mails))
+	}
+	_, err := s.ConfirmPasswordReset(ctx, &pb.ConfirmPasswordResetRequest{Token: mails[0].data["token"], NewPassword: "new correct horse"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("older token: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestPasswordResetUnknownEmail(t *testing.T) {
+	s, db, _ := newTestService(t)
+	passwordUser(t, s, db, "old correct horse")
+	store := newOneTimeTokenStore(t, db, "PasswordReset")
+
+	// 가입 여부가 드러나지 않도록 같은 응답을 주고 메일은 보내지 않는다.
+	resp, err := s.RequestPasswordReset(context.Background(), &pb.RequestPasswordResetRequest{Email: "nobody@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != passwordResetRequestedMessage {
+		t.Errorf("message = %q", resp.Message)
+	}
+	if len(store.tokens) != 0 || len(store.mails) != 0 {
+		t.Error("reset token was issued for an unknown email")
+	}
+}
+
+func TestConfirmPasswordResetRejected(t *testing.T) {
+	s, db, _ := newTestService(t)
+	userID, _ := passwordUser(t, s, db, "old correct horse")
+	store := newOneTimeTokenStore(t, db, "PasswordReset")
+	store.tokens = append(store.tokens, &oneTimeToken{
+		userID:    userID,
+		hash:      auth.HashToken("expired-token"),
+		expiresAt: time.Now().Add(-time.Minute),
+	})
+
+	for _, token := range []string{"expired-token", "unknown-token"} {
+		_, err := s.ConfirmPasswordReset(context.Background(), &pb.ConfirmPasswordResetRequest{Token: token, NewPassword: "new correct horse"})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: got %v, want InvalidArgument", token, err)
+		}
+	}
+	if db.called("UpdateUserPassword") != 0 {
+		t.Error("password was changed with a rejected token")
+	}
+}
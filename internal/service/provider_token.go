@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// providerTokenRefreshMargin 만료까지 이 시간보다 적게 남은 액세스 토큰은 미리 갱신한다.
+const providerTokenRefreshMargin = time.Minute
+
+// saveProviderToken 제공자 토큰을 암호화해 저장한다. 리프레시 토큰이 없으면 기존 것을 유지한다.
+// 암호화 키가 없으면 시작하지 않으므로 sealer 는 항상 있어야 한다. 없으면 로그인은 막지 않고 저장만 건너뛴다.
+func (s *AccountService) saveProviderToken(ctx context.Context, qtx *postgresql.Queries, identityID uuid.UUID, token *oauth.Token) error {
+	if s.sealer == nil {
+		s.logger.Error("Provider token not stored, key encryption key is not configured", slog.String("identity_id", identityID.String()))
+		return nil
+	}
+	params := postgresql.UpsertProviderTokenParams{
+		IdentityID:       identityID,
+		ExpiresAt:        sql.NullTime{Time: token.ExpiresAt, Valid: !token.ExpiresAt.IsZero()},
+		RefreshExpiresAt: sql.NullTime{Time: token.RefreshExpiresAt, Valid: !token.RefreshExpiresAt.IsZero()},
+	}
+	var err error
+	params.AccessToken, err = s.sealer.Seal([]byte(token.AccessToken), identityID[:])
+	if err != nil {
+		return fmt.Errorf("seal access token: %w", err)
+	}
+	if token.RefreshToken != "" {
+		params.RefreshToken, err = s.sealer.Seal([]byte(token.RefreshToken), identityID[:])
+		if err != nil {
+			return fmt.Errorf("seal refresh token: %w", err)
+		}
+	}
+	return qtx.UpsertProviderToken(ctx, params)
+}
+
+// providerAccessToken 사용자의 제공자 액세스 토큰. 만료되었거나 곧 만료되면 리프레시 토큰으로 갱신해 저장한다.
+// 동시에 여러 요청이 갱신하지 않도록 토큰 행을 잠그고 처리한다. 반환하는 에러는 gRPC status 이다.
+func (s *AccountService) providerAccessToken(ctx context.Context, logger *slog.Logger, userID uuid.UUID, providerName string) (*oauth.Token, error) {
+	if s.sealer == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "provider token encryption is not configured")
+	}
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	// 1. 저장된 토큰
+	stored, err := qtx.GetProviderTokenForUpdate(ctx, postgresql.GetProviderTokenForUpdateParams{
+		UserID:   userID,
+		Provider: provider.Name(),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "no %s token for user", provider.Name())
+		}
+		logger.Error("Failed to get provider token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get provider token: %v", err)
+	}
+	accessToken, err := s.sealer.Open(stored.AccessToken, stored.IdentityID[:])
+	if err != nil {
+		logger.Error("Failed to decrypt provider token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to decrypt provider token: %v", err)
+	}
+	token := &oauth.Token{AccessToken: string(accessToken)}
+	if stored.ExpiresAt.Valid {
+		token.ExpiresAt = stored.ExpiresAt.Time
+	}
+	if token.ExpiresAt.IsZero() || time.Until(token.ExpiresAt) > providerTokenRefreshMargin {
+		return token, nil
+	}
+
+	// 2. 만료된 토큰 갱신
+	refresher, ok := provider.(oauth.Refresher)
+	if !ok || stored.RefreshToken == nil {
+		logger.Warn("Provider token expired and cannot be refreshed")
+		return nil, status.Errorf(codes.FailedPrecondition, "%s token expired, user must sign in again", provider.Name())
+	}
+	if stored.RefreshExpiresAt.Valid && time.Now().After(stored.RefreshExpiresAt.Time) {
+		logger.Warn("Provider refresh token expired")
+		return nil, status.Errorf(codes.FailedPrecondition, "%s token expired, user must sign in again", provider.Name())
+	}
+	refreshToken, err := s.sealer.Open(stored.RefreshToken, stored.IdentityID[:])
+	if err != nil {
+		logger.Error("Failed to decrypt provider refresh token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to decrypt provider token: %v", err)
+	}
+	logger.Info("Refreshing provider token")
+	refreshed, err := refresher.Refresh(ctx, string(refreshToken))
+	if err != nil {
+		if errors.Is(err, oauth.ErrInvalidGrant) {
+			logger.Warn("Provider refresh token rejected", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.FailedPrecondition, "%s token expired, user must sign in again", provider.Name())
+		}
+		logger.Error("Failed to refresh provider token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Unavailable, "failed to refresh provider token")
+	}
+	if err = s.saveProviderToken(ctx, qtx, stored.IdentityID, refreshed); err != nil {
+		logger.Error("Failed to store provider token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store provider token: %v", err)
+	}
+	return refreshed, nil
+}
+
+// GetProviderAccessToken 사용자의 제공자 액세스 토큰을 반환한다 (service 역할 전용, HTTP 미노출).
+// 메시지 서비스가 로그인 이후에도 카카오톡 알림 등을 보낼 수 있도록 만료된 토큰은 갱신해서 준다.
+func (s *AccountService) GetProviderAccessToken(ctx context.Context, in *pb.GetProviderAccessTokenRequest) (*pb.GetProviderAccessTokenResponse, error) {
+	logger := s.logger.With("method", "GetProviderAccessToken", "target_user_id", in.UserId, "provider", in.Provider)
+
+	p, err := s.requireRole(ctx, roleService)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Debug("Provider access token requested")
+	userID, err := uuid.Parse(in.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id")
+	}
+
+	token, err := s.providerAccessToken(ctx, logger, userID, in.Provider)
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.GetProviderAccessTokenResponse{AccessToken: token.AccessToken}
+	if !token.ExpiresAt.IsZero() {
+		res.ExpiresAt = timestamppb.New(token.ExpiresAt)
+	}
+	return res, nil
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// refreshingProvider 리프레시 토큰으로 새 토큰을 받는 제공자
+type refreshingProvider struct {
+	*fakeProvider
+	refreshed *oauth.Token
+	err       error
+	refreshes []string // Refresh 에 넘어온 리프레시 토큰
+}
+
+func (p *refreshingProvider) Refresh(ctx context.Context, refreshToken string) (*oauth.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refreshes = append(p.refreshes, refreshToken)
+	return p.refreshed, p.err
+}
+
+// storedProviderToken 가짜 provider_tokens 테이블의 행. 토큰은 암호화된 채로 둔다.
+type storedProviderToken struct {
+	accessToken      []byte
+	refreshToken     []byte
+	expiresAt        driver.Value
+	refreshExpiresAt driver.Value
+}
+
+// providerTokenStore 한 사용자의 제공자 계정 하나와 그 토큰
+type providerTokenStore struct {
+	mu         sync.Mutex
+	userID     uuid.UUID
+	identityID uuid.UUID
+	provider   string
+	token      *storedProviderToken
+}
+
+func newProviderTokenStore(db *fakeDB, userID uuid.UUID, provider string) *providerTokenStore {
+	store := &providerTokenStore{userID: userID, identityID: uuid.New(), provider: provider}
+	db.handle("GetProviderTokenForUpdate", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		if store.token == nil || args[0] != store.userID.String() || args[1] != store.provider {
+			return nil, nil
+		}
+		var refreshToken driver.Value
+		if store.token.refreshToken != nil {
+			refreshToken = store.token.refreshToken
+		}
+		return [][]driver.Value{{
+			store.identityID.String(), store.token.accessToken, refreshToken,
+			store.token.expiresAt, store.token.refreshExpiresAt, time.Now(),
+		}}, nil
+	})
+	db.handle("UpsertProviderToken", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		if args[0] != store.identityID.String() {
+			return nil, fmt.Errorf("unknown identity %v", args[0])
+		}
+		next := &storedProviderToken{accessToken: args[1].([]byte), expiresAt: args[3], refreshExpiresAt: args[4]}
+		// 갱신 응답에 리프레시 토큰이 없으면 기존 토큰과 만료를 유지한다.
+		if refreshToken, _ := args[2].([]byte); refreshToken != nil {
+			next.refreshToken = refreshToken
+		} else if store.token != nil {
+			next.refreshToken, next.refreshExpiresAt = store.token.refreshToken, store.token.refreshExpiresAt
+		}
+		store.token = next
+		return affected(1), nil
+	})
+	return store
+}
+
+// save 토큰을 암호화해 저장한다.
+func (store *providerTokenStore) save(t *testing.T, s *AccountService, token *oauth.Token) {
+	t.Helper()
+	if err := s.saveProviderToken(context.Background(), postgresql.New(s.pg.GetDB()), store.identityID, token); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// open 저장된 토큰을 복호화한다.
+func (store *providerTokenStore) open(t *testing.T, s *AccountService) (string, string) {
+	t.Helper()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	accessToken, err := s.sealer.Open(store.token.accessToken, store.identityID[:])
+	if err != nil {
+		t.Fatalf("open access token: %v", err)
+	}
+	refreshToken, err := s.sealer.Open(store.token.refreshToken, store.identityID[:])
+	if err != nil {
+		t.Fatalf("open refresh token: %v", err)
+	}
+	return string(accessToken), string(refreshToken)
+}
+
+// withSealer s 에 임의의 키로 만든 Sealer 를 설정한다.
+func withSealer(t *testing.T, s *AccountService) {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	sealer, err := auth.NewSealer(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.sealer = sealer
+}
+
+// providerTokenService 카카오 토큰을 저장해 둔 사용자와 service 역할로 로그인한 context
+func providerTokenService(t *testing.T, token *oauth.Token) (*AccountService, *fakeDB, *providerTokenStore, *refreshingProvider, context.Context) {
+	t.Helper()
+	s, db, _ := newTestService(t)
+	withSealer(t, s)
+	provider := &refreshingProvider{fakeProvider: &fakeProvider{name: oauth.ProviderKakao}}
+	s.providers = map[string]oauth.Provider{oauth.ProviderKakao: provider}
+	store := newProviderTokenStore(db, uuid.New(), oauth.ProviderKakao)
+	store.save(t, s, token)
+	ctx, _, _ := signIn(t, s, uuid.New(), roleService)
+	return s, db, store, provider, ctx
+}
+
+func TestGetProviderAccessTokenValid(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	s, _, store, provider, ctx := providerTokenService(t, &oauth.Token{AccessToken: "kakao-access", RefreshToken: "kakao-refresh", ExpiresAt: expiresAt})
+
+	resp, err := s.GetProviderAccessToken(ctx, &pb.GetProviderAccessTokenRequest{UserId: store.userID.String(), Provider: oauth.ProviderKakao})
+	if err != nil {
+		t.Fatalf("GetProviderAccessToken: %v", err)
+	}
+	if resp.AccessToken != "kakao-access" || !resp.ExpiresAt.AsTime().Equal(expiresAt) {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(provider.refreshes) != 0 {
+		t.Error("valid token was refreshed")
+	}
+	// 저장된 토큰은 평문이 아니다.
+	if bytes.Contains(store.token.accessToken, []byte("kakao-access")) || bytes.Contains(store.token.refreshToken, []byte("kakao-refresh")) {
+		t.Error("provider token stored in plaintext")
+	}
+}
+
+func TestGetProviderAccessTokenRefreshesExpiredToken(t *testing.T) {
+	s, db, store, provider, ctx := providerTokenService(t, &oauth.Token{
+		AccessToken:      "old-access",
+		RefreshToken:     "kakao-refresh",
+		ExpiresAt:        time.Now().Add(30 * time.Second), // 갱신 여유 시간 안
+		RefreshExpiresAt: time.Now().Add(24 * time.Hour),
+	})
+	provider.refreshed = &oauth.Token{AccessToken: "new-access", ExpiresAt: time.Now().Add(6 * time.Hour)}
+
+	resp, err := s.GetProviderAccessToken(ctx, &pb.GetProviderAccessTokenRequest{UserId: store.userID.String(), Provider: oauth.ProviderKakao})
+	if err != nil {
+		t.Fatalf("GetProviderAccessToken: %v", err)
+	}
+	if resp.AccessToken != "new-access" {
+		t.Errorf("access token = %q, want the refreshed token", resp.AccessToken)
+	}
+	if len(provider.refreshes) != 1 || provider.refreshes[0] != "kakao-refresh" {
+		t.Errorf("refreshes = %v", provider.refreshes)
+	}
+	if db.called("UpsertProviderToken") != 2 {
+		t.Fatal("refreshed token was not stored")
+	}
+	// 갱신 응답에 리프레시 토큰이 없으면 기존 리프레시 토큰을 계속 쓴다.
+	if accessToken, refreshToken := store.open(t, s); accessToken != "new-access" || refreshToken != "kakao-refresh" {
+		t.Errorf("stored tokens = %q, %q", accessToken, refreshToken)
+	}
+}
+
+func TestGetProviderAccessTokenRejected(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name       string
+		token      *oauth.Token
+		refreshErr error
+		want       codes.Code
+		refreshes  int
+	}{
+		{"refresh token rejected", &oauth.Token{AccessToken: "a", RefreshToken: "r", ExpiresAt: expired}, fmt.Errorf("%w: kakao: expired", oauth.ErrInvalidGrant), codes.FailedPrecondition, 1},
+		{"provider unavailable", &oauth.Token{AccessToken: "a", RefreshToken: "r", ExpiresAt: expired}, fmt.Errorf("oauth: kakao token request: unexpected status 503"), codes.Unavailable, 1},
+		{"refresh token expired", &oauth.Token{AccessToken: "a", RefreshToken: "r", ExpiresAt: expired, RefreshExpiresAt: expired}, nil, codes.FailedPrecondition, 0},
+		{"no refresh token", &oauth.Token{AccessToken: "a", ExpiresAt: expired}, nil, codes.FailedPrecondition, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db, store, provider, ctx := providerTokenService(t, tt.token)
+			provider.err = tt.refreshErr
+
+			_, err := s.GetProviderAccessToken(ctx, &pb.GetProviderAccessTokenRequest{UserId: store.userID.String(), Provider: oauth.ProviderKakao})
+			if status.Code(err) != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if len(provider.refreshes) != tt.refreshes {
+				t.Errorf("refreshes = %v, want %d", provider.refreshes, tt.refreshes)
+			}
+			if db.called("UpsertProviderToken") != 1 {
+				t.Error("stored token was replaced")
+			}
+		})
+	}
+}
+
+func TestGetProviderAccessTokenNotFound(t *testing.T) {
+	s, _, _, _, ctx := providerTokenService(t, &oauth.Token{AccessToken: "kakao-access"})
+	_, err := s.GetProviderAccessToken(ctx, &pb.GetProviderAccessTokenRequest{UserId: uuid.NewString(), Provider: oauth.ProviderKakao})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("got %v, want NotFound", err)
+	}
+}
+
+func TestGetProviderAccessTokenRequiresServiceRole(t *testing.T) {
+	s, db, store, _, _ := providerTokenService(t, &oauth.Token{AccessToken: "kakao-access"})
+	ctx, _, _ := signIn(t, s, store.userID, roleCustomer)
+
+	_, err := s.GetProviderAccessToken(ctx, &pb.GetProviderAccessTokenRequest{UserId: store.userID.String(), Provider: oauth.ProviderKakao})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("got %v, want PermissionDenied", err)
+	}
+	if db.called("GetProviderTokenForUpdate") != 0 {
+		t.Error("provider token was read without the service role")
+	}
+}
+
+func TestSocialLoginStoresSealedProviderToken(t *testing.T) {
+	s, db, _ := newTestService(t)
+	withSealer(t, s)
+	store := newIdentityStore(db)
+	withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1"})
+	var upserts [][]driver.Value
+	db.handle("UpsertProviderToken", func(args []driver.Value) ([][]driver.Value, error) {
+		upserts = append(upserts, args)
+		return affected(1), nil
+	})
+
+	resp, err := socialLogin(t, s, oauth.ProviderKakao)
+	if err != nil {
+		t.Fatalf("SocialCallback: %v", err)
+	}
+	identities := store.identitiesOf(uuid.MustParse(resp.UserId))
+	if len(upserts) != 1 || len(identities) != 1 || upserts[0][0] != identities[0].id.String() {
+		t.Fatalf("upserts = %v, identities = %+v", upserts, identities)
+	}
+	// 토큰은 연결 ID 를 추가 인증 데이터로 암호화해 저장한다.
+	accessToken, err := s.sealer.Open(upserts[0][1].([]byte), identities[0].id[:])
+	if err != nil || string(accessToken) != "provider-access-code" {
+		t.Errorf("stored access token = %q, %v", accessToken, err)
+	}
+	otherIdentity := uuid.New()
+	if _, err := s.sealer.Open(upserts[0][2].([]byte), otherIdentity[:]); err == nil {
+		t.Error("refresh token opened with another identity")
+	}
+}
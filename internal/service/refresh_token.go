@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RefreshToken 리프레시 토큰으로 새 액세스/리프레시 토큰을 발급한다.
+// 사용된 리프레시 토큰은 소비 처리되며, 이미 소비된 토큰이 다시 들어오면
+// 탈취로 간주하고 해당 토큰 패밀리 전체를 폐기한다.
+func (s *AccountService) RefreshToken(ctx context.Context, in *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	logger := s.logger.With("method", "RefreshToken")
+	logger.Info("Starting token refresh")
+
+	claims, err := s.parseRefreshToken(in.RefreshToken)
+	if err != nil {
+		logger.Warn("Invalid refresh token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	logger.Debug("Looking up refresh token")
+	stored, err := qtx.GetRefreshTokenForUpdate(ctx, auth.HashToken(in.RefreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Warn("Refresh token not found", slog.String("subject", claims.Subject))
+			return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token")
+		}
+		logger.Error("Failed to get refresh token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get refresh token: %v", err)
+	}
+	logger = logger.With("user_id", stored.UserID.String(), "family_id", stored.FamilyID.String())
+
+	if stored.UserID.String() != claims.Subject {
+		logger.Warn("Refresh token subject mismatch", slog.String("subject", claims.Subject))
+		return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token")
+	}
+	if stored.RevokedAt.Valid {
+		logger.Warn("Revoked refresh token presented")
+		return nil, status.Errorf(codes.Unauthenticated, "refresh token revoked")
+	}
+	if stored.ConsumedAt.Valid {
+		// 이미 교환된 토큰의 재사용: 패밀리 전체를 폐기한다.
+		// err 가 nil 인 상태로 반환해야 폐기 내용이 커밋된다.
+		logger.Warn("Refresh token reuse detected, revoking token family")
+		if err = qtx.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+			logger.Error("Failed to revoke refresh token family", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to revoke refresh token family: %v", err)
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "refresh token reuse detected")
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		logger.Warn("Expired refresh token presented")
+		return nil, status.Errorf(codes.Unauthenticated, "refresh token expired")
+	}
+
+	// 세션 도입(000003) 전에 발급된 토큰은 000013 에서 지웠다. 세션 없는 토큰은 받지 않는다.
+	if !stored.SessionID.Valid {
+		logger.Warn("Refresh token has no session")
+		return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token")
+	}
+	logger.Debug("Checking session", slog.String("session_id", stored.SessionID.UUID.String()))
+	session, err := qtx.GetSession(ctx, stored.SessionID.UUID)
+	if err != nil {
+		logger.Error("Failed to get session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get session: %v", err)
+	}
+	if session.RevokedAt.Valid || time.Now().After(session.ExpiresAt) {
+		logger.Warn("Refresh token session is no longer active", slog.String("session_id", session.ID.String()))
+		return nil, status.Errorf(codes.Unauthenticated, "session expired or revoked")
+	}
+
+	logger.Debug("Consuming refresh token")
+	if err = qtx.ConsumeRefreshToken(ctx, stored.ID); err != nil {
+		logger.Error("Failed to consume refresh token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to consume refresh token: %v", err)
+	}
+
+	// 세션 사용 시각 갱신 및 만료 연장
+	session.LastSeenAt = time.Now()
+	session.ExpiresAt = session.LastSeenAt.Add(s.config.Auth.RefreshTokenTTL)
+	if err = qtx.TouchSession(ctx, postgresql.TouchSessionParams{
+		ID:        session.ID,
+		ExpiresAt: session.ExpiresAt,
+	}); err != nil {
+		logger.Error("Failed to update session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to update session: %v", err)
+	}
+
+	tokens, err := s.issueTokens(ctx, qtx, &session, stored.FamilyID)
+	if err != nil {
+		logger.Error("Failed to issue tokens", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
+	}
+
+	logger.Info("Token refresh successful")
+
+	return &pb.RefreshTokenResponse{
+		UserId:       stored.UserID.String(),
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// refreshTokenStore 세션 하나와 그 리프레시 토큰을 담은 가짜 테이블
+type refreshTokenStore struct {
+	mu      sync.Mutex
+	session postgresql.AccountSession
+	tokens  []*postgresql.AccountRefreshToken
+}
+
+func newRefreshTokenStore(db *fakeDB) *refreshTokenStore {
+	now := time.Now()
+	store := &refreshTokenStore{session: postgresql.AccountSession{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}}
+	db.handle("GetUserByID", func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{store.session.UserID.String(), "user@example.com", "", now}}, nil
+	})
+	db.handle("ListUserRoles", func(args []driver.Value) ([][]driver.Value, error) { return nil, nil })
+	db.handle("InsertRefreshToken", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		token := &postgresql.AccountRefreshToken{
+			ID:        uuid.MustParse(args[0].(string)),
+			UserID:    uuid.MustParse(args[1].(string)),
+			FamilyID:  uuid.MustParse(args[2].(string)),
+			TokenHash: args[4].(string),
+			ExpiresAt: args[5].(time.Time),
+		}
+		if args[3] != nil {
+			token.SessionID = uuid.NullUUID{UUID: uuid.MustParse(args[3].(string)), Valid: true}
+		}
+		store.tokens = append(store.tokens, token)
+		return affected(1), nil
+	})
+	db.handle("GetRefreshTokenForUpdate", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for _, t := range store.tokens {
+			if t.TokenHash == args[0] {
+				var sessionID, consumedAt, revokedAt driver.Value
+				if t.SessionID.Valid {
+					sessionID = t.SessionID.UUID.String()
+				}
+				if t.ConsumedAt.Valid {
+					consumedAt = t.ConsumedAt.Time
+				}
+				if t.RevokedAt.Valid {
+					revokedAt = t.RevokedAt.Time
+				}
+				return [][]driver.Value{{t.ID.String(), t.UserID.String(), t.FamilyID.String(), sessionID, t.ExpiresAt, consumedAt, revokedAt}}, nil
+			}
+		}
+		return nil, nil
+	})
+	db.handle("ConsumeRefreshToken", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for _, t := range store.tokens {
+			if t.ID.String() == args[0] {
+				t.ConsumedAt.Time, t.ConsumedAt.Valid = time.Now(), true
+			}
+		}
+		return affected(1), nil
+	})
+	db.handle("RevokeRefreshTokenFamily", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		n := 0
+		for _, t := range store.tokens {
+			if t.FamilyID.String() == args[0] && !t.RevokedAt.Valid {
+				t.RevokedAt.Time, t.RevokedAt.Valid = time.Now(), true
+				n++
+			}
+		}
+		return affected(n), nil
+	})
+	db.handle("GetSession", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		s := store.session
+		if s.ID.String() != args[0] {
+			return nil, nil
+		}
+		var revokedAt driver.Value
+		if s.RevokedAt.Valid {
+			revokedAt = s.RevokedAt.Time
+		}
+		return [][]driver.Value{{s.ID.String(), s.UserID.String(), s.DeviceName, s.UserAgent, s.IpAddress, s.CreatedAt, s.LastSeenAt, s.ExpiresAt, revokedAt}}, nil
+	})
+	db.handle("TouchSession", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.session.LastSeenAt = time.Now()
+		store.session.ExpiresAt = args[1].(time.Time)
+		return affected(1), nil
+	})
+	return store
+}
+
+// token 원문 리프레시 토큰의 저장된 행
+func (store *refreshTokenStore) token(refreshToken string) *postgresql.AccountRefreshToken {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, t := range store.tokens {
+		if t.TokenHash == auth.HashToken(refreshToken) {
+			return t
+		}
+	}
+	return nil
+}
+
+// login 세션의 첫 리프레시 토큰을 발급한다.
+func (store *refreshTokenStore) login(t *testing.T, s *AccountService, db *fakeDB) string {
+	t.Helper()
+	session := store.session
+	tokens, err := s.issueTokens(context.Background(), postgresql.New(db.GetDB()), &session, uuid.Nil)
+	if err != nil {
+		t.Fatalf("issueTokens: %v", err)
+	}
+	return tokens.RefreshToken
+}
+
+func refresh(s *AccountService, refreshToken string) (*pb.RefreshTokenResponse, error) {
+	return s.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: refreshToken})
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newRefreshTokenStore(db)
+	first := store.login(t, s, db)
+
+	resp, err := refresh(s, first)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if resp.RefreshToken == first {
+		t.Fatal("refresh token was not rotated")
+	}
+	if resp.UserId != store.session.UserID.String() {
+		t.Errorf("user id = %s, want %s", resp.UserId, store.session.UserID)
+	}
+	if !store.token(first).ConsumedAt.Valid {
+		t.Error("presented refresh token was not consumed")
+	}
+	second := store.token(resp.RefreshToken)
+	if second == nil {
+		t.Fatal("rotated refresh token was not stored")
+	}
+	if second.FamilyID != store.token(first).FamilyID || second.SessionID.UUID != store.session.ID {
+		t.Errorf("rotated token left the family or session: %+v", second)
+	}
+	if db.called("TouchSession") != 1 {
+		t.Error("session was not extended")
+	}
+
+	// 새 토큰은 다시 교환할 수 있다.
+	if _, err := refresh(s, resp.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken with rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newRefreshTokenStore(db)
+	first := store.login(t, s, db)
+
+	rotated, err := refresh(s, first)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	latest, err := refresh(s, rotated.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+
+	// 이미 교환된 토큰이 다시 오면 탈취로 보고 패밀리 전체를 폐기한다.
+	if _, err := refresh(s, first); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("replayed token: got %v, want Unauthenticated", err)
+	}
+	if db.called("RevokeRefreshTokenFamily") != 1 {
+		t.Fatal("token family was not revoked")
+	}
+	if !store.token(latest.RefreshToken).RevokedAt.Valid {
+		t.Error("latest token in the family is still valid")
+	}
+	if _, err := refresh(s, latest.RefreshToken); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("token from revoked family: got %v, want Unauthenticated", err)
+	}
+}
+
+func TestRefreshTokenRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(store *refreshTokenStore, token *postgresql.AccountRefreshToken)
+	}{
+		{"expired token", func(_ *refreshTokenStore, token *postgresql.AccountRefreshToken) {
+			token.ExpiresAt = time.Now().Add(-time.Second)
+		}},
+		{"revoked token", func(_ *refreshTokenStore, token *postgresql.AccountRefreshToken) {
+			token.RevokedAt.Time, token.RevokedAt.Valid = time.Now(), true
+		}},
+		{"revoked session", func(store *refreshTokenStore, _ *postgresql.AccountRefreshToken) {
+			store.session.RevokedAt.Time, store.session.RevokedAt.Valid = time.Now(), true
+		}},
+		{"expired session", func(store *refreshTokenStore, _ *postgresql.AccountRefreshToken) {
+			store.session.ExpiresAt = time.Now().Add(-time.Second)
+		}},
+		{"token without session", func(_ *refreshTokenStore, token *postgresql.AccountRefreshToken) {
+			token.SessionID = uuid.NullUUID{}
+		}},
+		{"other user", func(_ *refreshTokenStore, token *postgresql.AccountRefreshToken) {
+			token.UserID = uuid.New()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db, _ := newTestService(t)
+			store := newRefreshTokenStore(db)
+			refreshToken := store.login(t, s, db)
+			tt.setup(store, store.token(refreshToken))
+
+			if _, err := refresh(s, refreshToken); status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("got %v, want Unauthenticated", err)
+			}
+			if db.called("ConsumeRefreshToken") != 0 {
+				t.Error("rejected refresh token was consumed")
+			}
+		})
+	}
+
+	t.Run("unknown token", func(t *testing.T) {
+		s, db, _ := newTestService(t)
+		newRefreshTokenStore(db)
+		other, err := s.generateRefreshToken(uuid.New(), uuid.New(), time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := refresh(s, other); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("got %v, want Unauthenticated", err)
+		}
+	})
+}
+
+func TestRefreshTokenStoredAsHash(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newRefreshTokenStore(db)
+	refreshToken := store.login(t, s, db)
+
+	// DB 에는 원문이 아니라 해시만 남고, 교환할 때도 해시로 찾는다.
+	stored := store.token(refreshToken)
+	if stored == nil {
+		t.Fatal("refresh token is not stored by its hash")
+	}
+	if stored.TokenHash == refreshToken {
+		t.Fatal("refresh token is stored in plain text")
+	}
+	var lookups []driver.Value
+	db.handle("GetRefreshTokenForUpdate", func(args []driver.Value) ([][]driver.Value, error) {
+		lookups = append(lookups, args[0])
+		return nil, nil
+	})
+	if _, err := refresh(s, refreshToken); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got %v, want Unauthenticated", err)
+	}
+	if len(lookups) != 1 || lookups[0] != auth.HashToken(refreshToken) {
+		t.Errorf("refresh token looked up by %v, want its hash", lookups)
+	}
+}
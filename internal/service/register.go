@@ -7,9 +7,9 @@ import (
 	"log/slog"
 
 	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
-	pb "github.com/escape-ship/protos/gen"
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -18,6 +18,10 @@ func (s *AccountService) Register(ctx context.Context, req *pb.RegisterRequest)
 	logger := s.logger.With("method", "Register", "email", req.Email)
 	logger.Info("Starting user registration")
 
+	if req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
 	db := s.pg.GetDB()
 	querier := postgresql.New(db)
 
@@ -37,12 +41,20 @@ func (s *AccountService) Register(ctx context.Context, req *pb.RegisterRequest)
 		}
 	}()
 
+	if err = s.validatePassword("password", req.Password, req.Email); err != nil {
+		logger.Warn("Password rejected by policy")
+		return nil, err
+	}
+
 	// 이메일 중복 체크
 	logger.Debug("Checking email duplication")
 	_, err = qtx.GetUserByEmail(ctx, req.Email)
 	if err == nil {
 		logger.Warn("Email already registered", slog.String("email", req.Email))
-		return nil, status.Errorf(codes.AlreadyExists, "email already registered")
+		if !s.config.Auth.RegisterGenericResponse {
+			return nil, status.Errorf(codes.AlreadyExists, "email already registered")
+		}
+		return s.registerExistingEmail(ctx, qtx, logger, req)
 	}
 	if err != sql.ErrNoRows {
 		logger.Error("Failed to check email duplication", slog.String("error", err.Error()))
@@ -52,7 +64,7 @@ func (s *AccountService) Register(ctx context.Context, req *pb.RegisterRequest)
 
 	// 비밀번호 해시 생성
 	logger.Debug("Generating password hash")
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	passwordHash, err := s.hashPassword(req.Password)
 	if err != nil {
 		logger.Error("Failed to hash password", slog.String("error", err.Error()))
 		return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
@@ -64,7 +76,7 @@ func (s *AccountService) Register(ctx context.Context, req *pb.RegisterRequest)
 	returnedUserID, err := qtx.InsertUser(ctx, postgresql.InsertUserParams{
 		ID:           userID,
 		Email:        req.Email,
-		PasswordHash: string(passwordHash),
+		PasswordHash: passwordHash,
 	})
 	if err != nil {
 		logger.Error("Failed to register user",
@@ -73,11 +85,51 @@ func (s *AccountService) Register(ctx context.Context, req *pb.RegisterRequest)
 		return nil, status.Errorf(codes.Internal, "failed to register user: %v", err)
 	}
 
+	// 기본 역할 부여
+	if err = qtx.GrantUserRole(ctx, postgresql.GrantUserRoleParams{
+		UserID: returnedUserID,
+		Role:   roleCustomer,
+	}); err != nil {
+		logger.Error("Failed to grant default role",
+			slog.String("user_id", returnedUserID.String()),
+			slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to grant default role: %v", err)
+	}
+
+	// 인증 메일. 가입 트랜잭션과 함께 커밋되어야 발송된다.
+	if err = s.enqueueVerificationEmail(ctx, qtx, returnedUserID, req.Email); err != nil {
+		logger.Error("Failed to queue verification email",
+			slog.String("user_id", returnedUserID.String()),
+			slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to queue verification email: %v", err)
+	}
+
 	logger.Info("User registration successful",
 		slog.String("user_id", returnedUserID.String()),
 		slog.String("email", req.Email))
 
+	if s.config.Auth.RegisterGenericResponse {
+		return &pb.RegisterResponse{Message: registerGenericMessage}, nil
+	}
 	return &pb.RegisterResponse{
 		Message: fmt.Sprintf("Registration successful, user ID: %s", returnedUserID.String()),
 	}, nil
 }
+
+// registerGenericMessage auth.register_generic_response 일 때 가입 여부와 관계없이 주는 응답
+const registerGenericMessage = "Registration received, check your email to continue"
+
+// registerExistingEmail 이미 가입된 이메일로 가입을 시도하면 새 가입과 같은 응답을 주고 안내 메일을 보낸다.
+// 응답 시간으로 구분되지 않도록 새 가입처럼 비밀번호 해시도 계산한다.
+func (s *AccountService) registerExistingEmail(ctx context.Context, qtx *postgresql.Queries, logger *slog.Logger, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	if _, err := s.hashPassword(req.Password); err != nil {
+		logger.Error("Failed to hash password", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
+	}
+	if err := s.enqueueMail(ctx, qtx, mailer.KindAccountExists, req.Email, nil); err != nil {
+		logger.Error("Failed to queue account exists mail", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to queue account exists mail: %v", err)
+	}
+	logger.Info("Account exists mail queued")
+	return &pb.RegisterResponse{Message: registerGenericMessage}, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/escape-ship/accountsrv/internal/mailer"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// registerStore 가입 쿼리와 아웃박스를 등록한다. user@example.com 은 이미 가입되어 있다.
+func registerStore(t *testing.T, s *AccountService, db *fakeDB) *oneTimeTokenStore {
+	t.Helper()
+	passwordUser(t, s, db, "correct horse")
+	store := newOneTimeTokenStore(t, db, "EmailVerification")
+	db.handle("InsertUser", func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{args[0]}}, nil
+	})
+	db.handle("GrantUserRole", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	return store
+}
+
+func TestRegisterGenericResponse(t *testing.T) {
+	s, db, _ := newTestService(t)
+	s.config.Auth.RegisterGenericResponse = true
+	store := registerStore(t, s, db)
+	ctx := context.Background()
+
+	created, err := s.Register(ctx, &pb.RegisterRequest{Email: "fresh@example.com", Password: "new correct horse"})
+	if err != nil {
+		t.Fatalf("Register new email: %v", err)
+	}
+	existing, err := s.Register(ctx, &pb.RegisterRequest{Email: "user@example.com", Password: "new correct horse"})
+	if err != nil {
+		t.Fatalf("Register existing email: %v", err)
+	}
+	// 새 가입과 기존 이메일은 같은 응답을 받고, 차이는 받은 메일로만 알 수 있다.
+	if created.Message != existing.Message || created.Message != registerGenericMessage {
+		t.Errorf("new = %q, existing = %q", created.Message, existing.Message)
+	}
+	if db.called("InsertUser") != 1 {
+		t.Errorf("InsertUser called %d times, want 1", db.called("InsertUser"))
+	}
+	if mails := store.mailsOf(mailer.KindEmailVerification); len(mails) != 1 || mails[0].to != "fresh@example.com" {
+		t.Errorf("verification mails = %+v", mails)
+	}
+	if mails := store.mailsOf(mailer.KindAccountExists); len(mails) != 1 || mails[0].to != "user@example.com" {
+		t.Errorf("account exists mails = %+v", mails)
+	}
+}
+
+func TestRegisterExistingEmailWithoutGenericResponse(t *testing.T) {
+	s, db, _ := newTestService(t)
+	registerStore(t, s, db)
+
+	_, err := s.Register(context.Background(), &pb.RegisterRequest{Email: "user@example.com", Password: "new correct horse"})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("got %v, want AlreadyExists", err)
+	}
+	resp, err := s.Register(context.Background(), &pb.RegisterRequest{Email: "fresh@example.com", Password: "new correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Message, "Registration successful") {
+		t.Errorf("message = %q", resp.Message)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 기본 역할. account.roles 에 시드되어 있다.
+const (
+	roleCustomer = "customer" // 가입 시 부여
+	roleAdmin    = "admin"    // 백오피스
+	roleService  = "service"  // 제공자 토큰을 조회하는 내부 서비스
+)
+
+// roleScopes 역할별로 액세스 토큰 scope 클레임에 넣는 scope. 여기에 없는 역할은 scope 를 주지 않는다.
+var roleScopes = map[string][]string{
+	roleCustomer: {"account"},
+	roleAdmin:    {"account", "admin"},
+	roleService:  {"provider_token"},
+}
+
+// scopeForRoles 역할에서 얻은 scope 를 중복 없이 정렬해 공백으로 이은 값 (RFC 9068 scope 클레임)
+func scopeForRoles(roles []string) string {
+	var scopes []string
+	for _, role := range roles {
+		scopes = append(scopes, roleScopes[role]...)
+	}
+	slices.Sort(scopes)
+	return strings.Join(slices.Compact(scopes), " ")
+}
+
+// hasRole 토큰에 역할이 포함되어 있는지 여부
+func (p *principal) hasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// requireRole 요청을 인증하고 주체가 role 을 가지고 있는지 확인한다.
+func (s *AccountService) requireRole(ctx context.Context, role string) (*principal, error) {
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.hasRole(role) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s role required", role)
+	}
+	return p, nil
+}
+
+// GrantRole 사용자에게 역할을 부여한다. 이미 가진 역할이면 그대로 성공한다.
+func (s *AccountService) GrantRole(ctx context.Context, in *pb.GrantRoleRequest) (*pb.GrantRoleResponse, error) {
+	logger := s.logger.With("method", "GrantRole", "target_user_id", in.UserId, "role", in.Role)
+
+	p, err := s.requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Granting role")
+
+	userID, err := uuid.Parse(in.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id")
+	}
+
+	querier := postgresql.New(s.pg.GetDB())
+	if err := s.checkRoleTarget(ctx, querier, userID, in.Role); err != nil {
+		return nil, err
+	}
+	if err := querier.GrantUserRole(ctx, postgresql.GrantUserRoleParams{
+		UserID: userID,
+		Role:   in.Role,
+	}); err != nil {
+		logger.Error("Failed to grant role", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to grant role: %v", err)
+	}
+
+	roles, err := querier.ListUserRoles(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to list roles", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to list roles: %v", err)
+	}
+	logger.Info("Role granted")
+	return &pb.GrantRoleResponse{Roles: roles}, nil
+}
+
+// RevokeRole 사용자의 역할을 회수한다.
+func (s *AccountService) RevokeRole(ctx context.Context, in *pb.RevokeRoleRequest) (*pb.RevokeRoleResponse, error) {
+	logger := s.logger.With("method", "RevokeRole", "target_user_id", in.UserId, "role", in.Role)
+
+	p, err := s.requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Revoking role")
+
+	userID, err := uuid.Parse(in.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id")
+	}
+	if userID == p.UserID && in.Role == roleAdmin {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot revoke own admin role")
+	}
+
+	querier := postgresql.New(s.pg.GetDB())
+	if err := s.checkRoleTarget(ctx, querier, userID, in.Role); err != nil {
+		return nil, err
+	}
+	rows, err := querier.RevokeUserRole(ctx, postgresql.RevokeUserRoleParams{
+		UserID: userID,
+		Role:   in.Role,
+	})
+	if err != nil {
+		logger.Error("Failed to revoke role", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to revoke role: %v", err)
+	}
+	if rows == 0 {
+		return nil, status.Errorf(codes.NotFound, "user does not have role %s", in.Role)
+	}
+
+	roles, err := querier.ListUserRoles(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to list roles", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to list roles: %v", err)
+	}
+	logger.Info("Role revoked")
+	return &pb.RevokeRoleResponse{Roles: roles}, nil
+}
+
+// checkRoleTarget 역할 변경 대상 사용자와 역할이 존재하는지 확인한다.
+func (s *AccountService) checkRoleTarget(ctx context.Context, querier *postgresql.Queries, userID uuid.UUID, role string) error {
+	if _, err := querier.GetUserByID(ctx, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return status.Errorf(codes.NotFound, "user not found")
+		}
+		return status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if _, err := querier.GetRole(ctx, role); err != nil {
+		if err == sql.ErrNoRows {
+			return status.Errorf(codes.NotFound, "role %q not found", role)
+		}
+		return status.Errorf(codes.Internal, "failed to get role: %v", err)
+	}
+	return nil
+}
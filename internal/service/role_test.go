@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// roleStore 사용자별 역할을 담은 가짜 user_roles 테이블
+type roleStore struct {
+	mu    sync.Mutex
+	roles map[string][]string // user_id -> 정렬된 역할
+}
+
+func newRoleStore(db *fakeDB, users ...uuid.UUID) *roleStore {
+	store := &roleStore{roles: make(map[string][]string)}
+	for _, id := range users {
+		store.roles[id.String()] = []string{roleCustomer}
+	}
+	db.handle("GetUserByID", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		if _, ok := store.roles[args[0].(string)]; !ok {
+			return nil, nil
+		}
+		return [][]driver.Value{{args[0], "user@example.com", "", time.Now()}}, nil
+	})
+	db.handle("GetRole", func(args []driver.Value) ([][]driver.Value, error) {
+		if _, ok := roleScopes[args[0].(string)]; !ok {
+			return nil, nil
+		}
+		return [][]driver.Value{{args[0], "", time.Now()}}, nil
+	})
+	db.handle("GrantUserRole", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		roles := store.roles[args[0].(string)]
+		if slices.Contains(roles, args[1].(string)) {
+			return affected(0), nil
+		}
+		roles = append(roles, args[1].(string))
+		slices.Sort(roles)
+		store.roles[args[0].(string)] = roles
+		return affected(1), nil
+	})
+	db.handle("RevokeUserRole", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		roles := store.roles[args[0].(string)]
+		i := slices.Index(roles, args[1].(string))
+		if i < 0 {
+			return affected(0), nil
+		}
+		store.roles[args[0].(string)] = slices.Delete(roles, i, i+1)
+		return affected(1), nil
+	})
+	db.handle("ListUserRoles", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		var rows [][]driver.Value
+		for _, role := range store.roles[args[0].(string)] {
+			rows = append(rows, []driver.Value{role})
+		}
+		return rows, nil
+	})
+	return store
+}
+
+func TestGrantAndRevokeRole(t *testing.T) {
+	s, db, _ := newTestService(t)
+	adminID, userID := uuid.New(), uuid.New()
+	newRoleStore(db, adminID, userID)
+	ctx, _, _ := signIn(t, s, adminID, roleAdmin, roleCustomer)
+
+	granted, err := s.GrantRole(ctx, &pb.GrantRoleRequest{UserId: userID.String(), Role: roleAdmin})
+	if err != nil {
+		t.Fatalf("GrantRole: %v", err)
+	}
+	if want := []string{roleAdmin, roleCustomer}; !slices.Equal(granted.Roles, want) {
+		t.Errorf("roles after grant = %v, want %v", granted.Roles, want)
+	}
+	// 이미 가진 역할을 다시 부여해도 성공한다.
+	if _, err := s.GrantRole(ctx, &pb.GrantRoleRequest{UserId: userID.String(), Role: roleAdmin}); err != nil {
+		t.Errorf("granting a held role: %v", err)
+	}
+
+	revoked, err := s.RevokeRole(ctx, &pb.RevokeRoleRequest{UserId: userID.String(), Role: roleAdmin})
+	if err != nil {
+		t.Fatalf("RevokeRole: %v", err)
+	}
+	if want := []string{roleCustomer}; !slices.Equal(revoked.Roles, want) {
+		t.Errorf("roles after revoke = %v, want %v", revoked.Roles, want)
+	}
+}
+
+func TestRoleChangesRejected(t *testing.T) {
+	s, db, _ := newTestService(t)
+	adminID, userID := uuid.New(), uuid.New()
+	newRoleStore(db, adminID, userID)
+	adminCtx, _, _ := signIn(t, s, adminID, roleAdmin)
+	customerCtx, _, _ := signIn(t, s, userID, roleCustomer)
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"grant without admin role", func() error {
+			_, err := s.GrantRole(customerCtx, &pb.GrantRoleRequest{UserId: userID.String(), Role: roleAdmin})
+			return err
+		}, codes.PermissionDenied},
+		{"revoke without admin role", func() error {
+			_, err := s.RevokeRole(customerCtx, &pb.RevokeRoleRequest{UserId: adminID.String(), Role: roleAdmin})
+			return err
+		}, codes.PermissionDenied},
+		{"revoke own admin role", func() error {
+			_, err := s.RevokeRole(adminCtx, &pb.RevokeRoleRequest{UserId: adminID.String(), Role: roleAdmin})
+			return err
+		}, codes.FailedPrecondition},
+		{"unknown role", func() error {
+			_, err := s.GrantRole(adminCtx, &pb.GrantRoleRequest{UserId: userID.String(), Role: "owner"})
+			return err
+		}, codes.NotFound},
+		{"unknown user", func() error {
+			_, err := s.GrantRole(adminCtx, &pb.GrantRoleRequest{UserId: uuid.NewString(), Role: roleAdmin})
+			return err
+		}, codes.NotFound},
+		{"role not held", func() error {
+			_, err := s.RevokeRole(adminCtx, &pb.RevokeRoleRequest{UserId: userID.String(), Role: roleService})
+			return err
+		}, codes.NotFound},
+		{"invalid user id", func() error {
+			_, err := s.GrantRole(adminCtx, &pb.GrantRoleRequest{UserId: "42", Role: roleAdmin})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); status.Code(err) != tt.want {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if db.called("GrantUserRole") != 0 || db.called("RevokeUserRole") != 1 {
+		t.Error("rejected role change reached the database")
+	}
+}
+
+func TestIssuedAccessTokenCarriesRoles(t *testing.T) {
+	s, db, _ := newTestService(t)
+	userID := uuid.New()
+	store := newRoleStore(db, userID)
+	store.roles[userID.String()] = []string{roleAdmin, roleCustomer}
+	db.handle("InsertRefreshToken", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+
+	session := &postgresql.AccountSession{
+		ID:         uuid.New(),
+		UserID:     userID,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+	tokens, err := s.issueTokens(context.Background(), postgresql.New(db.GetDB()), session, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.verifyAccessToken(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.hasRole(roleAdmin) || !p.hasRole(roleCustomer) || p.hasRole(roleService) {
+		t.Errorf("roles = %v, want admin and customer", p.Roles)
+	}
+}
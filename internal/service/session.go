@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// sessionCache Redis에 미러링되는 세션 정보
+type sessionCache struct {
+	UserID     string    `json:"user_id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func sessionKey(sessionID uuid.UUID) string {
+	return fmt.Sprintf("session:%s", sessionID.String())
+}
+
+// createSession 새 로그인 세션을 DB에 생성한다. 요청 메타데이터의 User-Agent 와 IP 를 함께 기록한다.
+func (s *AccountService) createSession(ctx context.Context, qtx *postgresql.Queries, userID uuid.UUID, deviceName string) (*postgresql.AccountSession, error) {
+	userAgent, ip := s.clientInfo(ctx)
+	now := time.Now()
+	session := &postgresql.AccountSession{
+		ID:         uuid.New(),
+		UserID:     userID,
+		DeviceName: deviceName,
+		UserAgent:  userAgent,
+		IpAddress:  ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.config.Auth.RefreshTokenTTL),
+	}
+	if err := qtx.InsertSession(ctx, postgresql.InsertSessionParams{
+		ID:         session.ID,
+		UserID:     session.UserID,
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IpAddress:  session.IpAddress,
+		ExpiresAt:  session.ExpiresAt,
+	}); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// cacheSession 세션을 만료 시각까지 Redis에 저장한다.
+func (s *AccountService) cacheSession(ctx context.Context, session *postgresql.AccountSession) error {
+	value, err := json.Marshal(sessionCache{
+		UserID:     session.UserID.String(),
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IpAddress,
+		LastSeenAt: session.LastSeenAt,
+	})
+	if err != nil {
+		return err
+	}
+	return s.RedisClient.RedisClient.Set(ctx, sessionKey(session.ID), value, time.Until(session.ExpiresAt)).Err()
+}
+
+// isSessionActive Redis에 userID 의 세션이 살아 있는지 확인한다.
+// 다른 사용자의 세션 ID 를 넣은 토큰이 그 세션으로 통과하지 않도록 세션의 사용자까지 비교한다.
+func (s *AccountService) isSessionActive(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+	value, err := s.RedisClient.RedisClient.Get(ctx, sessionKey(sessionID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var cached sessionCache
+	if err := json.Unmarshal(value, &cached); err != nil {
+		return false, fmt.Errorf("decode session: %w", err)
+	}
+	return cached.UserID == userID.String(), nil
+}
+
+// endSession 세션과 세션에 속한 리프레시 토큰을 폐기한다.
+// 사용자의 활성 세션이 아니면 false 를 반환한다. Redis 의 세션은 호출한 쪽이 커밋한 뒤 uncacheSessions 로 지운다.
+func (s *AccountService) endSession(ctx context.Context, qtx *postgresql.Queries, userID, sessionID uuid.UUID) (bool, error) {
+	rows, err := qtx.RevokeSession(ctx, postgresql.RevokeSessionParams{
+		ID:     sessionID,
+		UserID: userID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	if err := qtx.RevokeSessionRefreshTokens(ctx, uuid.NullUUID{UUID: sessionID, Valid: true}); err != nil {
+		return false, fmt.Errorf("failed to revoke session refresh tokens: %w", err)
+	}
+	return true, nil
+}
+
+// endUserSessions exceptSessionID 를 제외한 사용자의 모든 세션과 리프레시 토큰을 폐기하고 폐기한 세션 ID 를 반환한다.
+// exceptSessionID 가 uuid.Nil 이면 모든 세션을 끝낸다. Redis 의 세션은 호출한 쪽이 커밋한 뒤 uncacheSessions 로 지운다.
+func (s *AccountService) endUserSessions(ctx context.Context, qtx *postgresql.Queries, userID, exceptSessionID uuid.UUID) ([]uuid.UUID, error) {
+	sessionIDs, err := qtx.RevokeUserSessions(ctx, postgresql.RevokeUserSessionsParams{
+		UserID:          userID,
+		ExceptSessionID: exceptSessionID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := qtx.RevokeUserRefreshTokens(ctx, postgresql.RevokeUserRefreshTokensParams{
+		UserID:          userID,
+		ExceptSessionID: exceptSessionID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return sessionIDs, nil
+}
+
+// uncacheSessions 폐기가 커밋된 세션을 Redis 에서 지운다.
+// 커밋 전에 지우면 롤백됐을 때 DB 에는 살아 있는 세션이 Redis 에서만 사라지므로 반드시 커밋한 뒤에 호출한다.
+// 실패하면 그 세션의 액세스 토큰이 만료될 때까지 통과하므로 에러로 남긴다.
+func (s *AccountService) uncacheSessions(ctx context.Context, logger *slog.Logger, sessionIDs []uuid.UUID) {
+	if len(sessionIDs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		keys = append(keys, sessionKey(id))
+	}
+	if err := s.RedisClient.RedisClient.Del(ctx, keys...).Err(); err != nil {
+		logger.Error("Failed to delete revoked sessions from Redis", slog.Int("sessions", len(sessionIDs)), slog.String("error", err.Error()))
+	}
+}
+
+// ListSessions 현재 사용자의 활성 세션 목록을 반환한다.
+func (s *AccountService) ListSessions(ctx context.Context, in *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	logger := s.logger.With("method", "ListSessions")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Debug("Listing active sessions")
+
+	querier := postgresql.New(s.pg.GetDB())
+	sessions, err := querier.ListActiveSessionsByUser(ctx, p.UserID)
+	if err != nil {
+		logger.Error("Failed to list sessions", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+
+	res := &pb.ListSessionsResponse{Sessions: make([]*pb.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, &pb.Session{
+			SessionId:  session.ID.String(),
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IpAddress:  session.IpAddress,
+			CreatedAt:  timestamppb.New(session.CreatedAt),
+			LastSeenAt: timestamppb.New(session.LastSeenAt),
+			Current:    session.ID == p.SessionID,
+		})
+	}
+	return res, nil
+}
+
+// RevokeSession 현재 사용자의 세션 하나를 폐기한다.
+func (s *AccountService) RevokeSession(ctx context.Context, in *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	logger := s.logger.With("method", "RevokeSession", "session_id", in.SessionId)
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Revoking session")
+
+	sessionID, err := uuid.Parse(in.SessionId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid session id")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	var endedSessions []uuid.UUID
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			if commitErr := tx.Commit(); commitErr != nil {
+				logger.Error("Failed to commit transaction", slog.String("error", commitErr.Error()))
+				return
+			}
+			s.uncacheSessions(ctx, logger, endedSessions)
+		}
+	}()
+
+	found, err := s.endSession(ctx, qtx, p.UserID, sessionID)
+	if err != nil {
+		logger.Error("Failed to revoke session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+	}
+	if !found {
+		logger.Warn("Session not found")
+		return nil, status.Errorf(codes.NotFound, "session not found")
+	}
+	endedSessions = []uuid.UUID{sessionID}
+
+	logger.Info("Session revoked")
+	return &pb.RevokeSessionResponse{}, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListSessions(t *testing.T) {
+	s, db, _ := newTestService(t)
+	userID := uuid.New()
+	ctx, current, _ := signIn(t, s, userID, roleCustomer)
+	other := uuid.New()
+	now := time.Now()
+	db.handle("ListActiveSessionsByUser", func(args []driver.Value) ([][]driver.Value, error) {
+		if args[0] != userID.String() {
+			t.Errorf("listed sessions of %v", args[0])
+		}
+		return [][]driver.Value{
+			{current.ID.String(), userID.String(), "laptop", "Firefox", "192.0.2.1", now, now, now.Add(time.Hour), nil},
+			{other.String(), userID.String(), "phone", "Safari", "192.0.2.2", now, now, now.Add(time.Hour), nil},
+		}, nil
+	})
+
+	resp, err := s.ListSessions(ctx, &pb.ListSessionsRequest{})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(resp.Sessions))
+	}
+	if got := resp.Sessions[0]; got.SessionId != current.ID.String() || !got.Current || got.DeviceName != "laptop" {
+		t.Errorf("current session = %+v", got)
+	}
+	if got := resp.Sessions[1]; got.SessionId != other.String() || got.Current || got.IpAddress != "192.0.2.2" {
+		t.Errorf("other session = %+v", got)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	s, db, fr := newTestService(t)
+	userID := uuid.New()
+	ctx, _, _ := signIn(t, s, userID, roleCustomer)
+	_, other, _ := signIn(t, s, userID, roleCustomer)
+	db.handle("RevokeSession", func(args []driver.Value) ([][]driver.Value, error) {
+		if args[0] != other.ID.String() || args[1] != userID.String() {
+			return affected(0), nil
+		}
+		return affected(1), nil
+	})
+	db.handle("RevokeSessionRefreshTokens", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+
+	if _, err := s.RevokeSession(ctx, &pb.RevokeSessionRequest{SessionId: other.ID.String()}); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, ok := fr.value(sessionKey(other.ID)); ok {
+		t.Error("revoked session is still cached in Redis")
+	}
+	if db.called("RevokeSessionRefreshTokens") != 1 {
+		t.Error("refresh tokens of the session were not revoked")
+	}
+
+	// 다른 사용자의 세션이거나 없는 세션은 찾을 수 없다고 답한다.
+	if _, err := s.RevokeSession(ctx, &pb.RevokeSessionRequest{SessionId: uuid.NewString()}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown session: got %v, want NotFound", err)
+	}
+	if _, err := s.RevokeSession(ctx, &pb.RevokeSessionRequest{SessionId: "not-a-uuid"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("invalid session id: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestEndedSessionsStayCachedOnRollback(t *testing.T) {
+	s, db, fr := newTestService(t)
+	userID := uuid.New()
+	ctx, current, _ := signIn(t, s, userID, roleCustomer)
+	_, other, _ := signIn(t, s, userID, roleCustomer)
+	passwordHash, err := s.hashPassword("old correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.handle("GetUserByID", func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{userID.String(), "user@example.com", passwordHash, time.Now()}}, nil
+	})
+	db.handle("UpdateUserPassword", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	db.handle("InvalidatePasswordResetTokens", func(args []driver.Value) ([][]driver.Value, error) { return affected(0), nil })
+	db.handle("RevokeUserSessions", func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{other.ID.String()}}, nil
+	})
+	db.handle("RevokeUserRefreshTokens", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	// 세션을 폐기한 뒤 같은 트랜잭션의 메일 적재가 실패한다.
+	db.handle("InsertOutboxMessage", func(args []driver.Value) ([][]driver.Value, error) {
+		return nil, errors.New("connection reset")
+	})
+
+	_, err = s.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		CurrentPassword:      "old correct horse",
+		NewPassword:          "new correct horse",
+		SignOutOtherSessions: true,
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("got %v, want Internal", err)
+	}
+	if db.called("RevokeUserSessions") != 1 {
+		t.Fatal("other sessions were not revoked")
+	}
+	// 트랜잭션이 롤백되면 세션은 DB 에 살아 있으므로 Redis 에서도 지우지 않는다.
+	for _, id := range []uuid.UUID{current.ID, other.ID} {
+		if _, ok := fr.value(sessionKey(id)); !ok {
+			t.Errorf("session %s was removed from Redis although the transaction rolled back", id)
+		}
+	}
+
+	db.handle("InsertOutboxMessage", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	if _, err := s.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		CurrentPassword:      "old correct horse",
+		NewPassword:          "new correct horse",
+		SignOutOtherSessions: true,
+	}); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if _, ok := fr.value(sessionKey(other.ID)); ok {
+		t.Error("other session is still cached after the commit")
+	}
+	if _, ok := fr.value(sessionKey(current.ID)); !ok {
+		t.Error("current session was signed out")
+	}
+}
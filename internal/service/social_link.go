@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// saveSocialLink 연결 확인을 기다리는 동안 연결 정보를 저장하고 클라이언트에 넘길 토큰을 반환한다.
+func (s *AccountService) saveSocialLink(ctx context.Context, link *socialLink) (string, error) {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	value, err := json.Marshal(link)
+	if err != nil {
+		return "", err
+	}
+	key := "social_link:" + auth.HashToken(token)
+	if err := s.RedisClient.RedisClient.Set(ctx, key, value, s.config.Auth.SocialLinkTTL).Err(); err != nil {
+		return "", fmt.Errorf("store social link: %w", err)
+	}
+	return token, nil
+}
+
+// takeSocialLink 연결 정보를 꺼내면서 지운다 (한 번만 사용). 없으면 nil 이다.
+func (s *AccountService) takeSocialLink(ctx context.Context, token string) (*socialLink, error) {
+	value, err := s.RedisClient.RedisClient.GetDel(ctx, "social_link:"+auth.HashToken(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var link socialLink
+	if err := json.Unmarshal(value, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ConfirmSocialLink 기존 계정의 주인임을 확인한 뒤 제공자 계정을 연결하고 로그인한다.
+// 링크 토큰은 제공자 콜백을 받은 사람이면 누구나 가지므로 이것만으로는 연결하지 않는다 (proveAccountOwner).
+// 링크 토큰은 한 번만 쓸 수 있어 확인에 실패하면 소셜 로그인부터 다시 해야 한다.
+// 확인 후에는 제공자가 인증한 이메일이 계정 이메일과 같으므로, 계정 이메일이 미인증이었다면 인증 처리한다.
+// 제공자 토큰은 Redis 에 두지 않으므로 다음 소셜 로그인 때 저장된다.
+func (s *AccountService) ConfirmSocialLink(ctx context.Context, in *pb.ConfirmSocialLinkRequest) (*pb.ConfirmSocialLinkResponse, error) {
+	logger := s.logger.With("method", "ConfirmSocialLink")
+	logger.Info("Confirming social account link")
+
+	if in.LinkToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "link_token is required")
+	}
+
+	_, ip := s.clientInfo(ctx)
+	ipScope := ipThrottleScope(ip)
+	if err := s.checkLoginThrottle(ctx, ipScope); err != nil {
+		logger.Warn("Social link rejected by throttle", slog.String("ip", ip), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	link, err := s.takeSocialLink(ctx, in.LinkToken)
+	if err != nil {
+		logger.Error("Failed to get social link", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get social link: %v", err)
+	}
+	if link == nil {
+		logger.Warn("Unknown or expired link token")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid or expired link token")
+	}
+	logger = logger.With("user_id", link.UserID.String(), "provider", link.Provider)
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	// 1. 확인을 기다리는 동안 다른 계정에 연결되지 않았는지 확인
+	alreadyLinked := false
+	existing, err := qtx.GetUserIdentity(ctx, postgresql.GetUserIdentityParams{
+		Provider:       link.Provider,
+		ProviderUserID: link.ProviderUserID,
+	})
+	switch {
+	case err == nil && existing.UserID != link.UserID:
+		logger.Warn("Provider account already linked to another user")
+		return nil, status.Errorf(codes.AlreadyExists, "%s account is already linked to another user", link.Provider)
+	case err == nil:
+		alreadyLinked = true
+	case err != sql.ErrNoRows:
+		logger.Error("Failed to get identity", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get identity: %v", err)
+	}
+
+	user, err := qtx.GetUserByID(ctx, link.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+
+	// 2. 기존 계정의 주인인지 확인
+	if err = s.proveAccountOwner(ctx, qtx, logger, user, in, ipScope); err != nil {
+		return nil, err
+	}
+
+	// 3. 연결 및 이메일 인증
+	if !alreadyLinked {
+		if _, err = s.linkIdentity(ctx, qtx, link.UserID, link.Provider, link.ProviderUserID, link.Email); err != nil {
+			logger.Error("Failed to link identity", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to link identity: %v", err)
+		}
+		logger.Info("Social account linked")
+	}
+	emailVerified := user.EmailVerifiedAt.Valid
+	if !emailVerified && user.Email == link.Email {
+		if err = qtx.MarkEmailVerified(ctx, link.UserID); err != nil {
+			logger.Error("Failed to mark email verified", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+		}
+		emailVerified = true
+	}
+
+	// 4. 로그인
+	res, err := s.completeSocialLogin(ctx, qtx, logger, &socialUser{ID: link.UserID, HasEmail: user.Email != "", EmailVerified: emailVerified}, in.DeviceName)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ConfirmSocialLinkResponse{
+		UserId:        res.UserId,
+		AccessToken:   res.AccessToken,
+		RefreshToken:  res.RefreshToken,
+		EmailVerified: res.EmailVerified,
+		MfaRequired:   res.MfaRequired,
+		MfaToken:      res.MfaToken,
+	}, nil
+}
+
+// proveAccountOwner 요청자가 연결할 기존 계정의 주인인지 확인한다.
+// 그 계정으로 로그인한 액세스 토큰, 패스키 서명, 비밀번호 순으로 하나를 받는다.
+// 실패는 Login 과 같이 로그인 실패로 센다. 반환하는 에러는 gRPC status 이다.
+func (s *AccountService) proveAccountOwner(ctx context.Context, qtx *postgresql.Queries, logger *slog.Logger, user postgresql.GetUserByIDRow, in *pb.ConfirmSocialLinkRequest, ipScope string) error {
+	// 1. 이미 로그인한 세션
+	if _, err := bearerToken(ctx); err == nil {
+		p, err := s.authenticate(ctx)
+		if err != nil {
+			return err
+		}
+		if p.UserID != user.ID {
+			logger.Warn("Access token belongs to another user", slog.String("token_user_id", p.UserID.String()))
+			return status.Errorf(codes.PermissionDenied, "access token does not belong to the account being linked")
+		}
+		logger.Info("Account ownership proven by session")
+		return nil
+	}
+
+	// 2. 패스키
+	if in.PasskeySessionId != "" || in.PasskeyCredential != "" {
+		wa, err := s.webAuthn()
+		if err != nil {
+			logger.Error("Invalid WebAuthn configuration", slog.String("error", err.Error()))
+			return status.Errorf(codes.FailedPrecondition, "passkeys are not configured")
+		}
+		passkeyUser, err := s.verifyPasskeyAssertion(ctx, qtx, logger, wa, in.PasskeySessionId, in.PasskeyCredential)
+		if err != nil {
+			if status.Code(err) == codes.Unauthenticated {
+				s.recordLoginFailureOrLog(ctx, logger, ipScope)
+			}
+			return err
+		}
+		if passkeyUser.id != user.ID {
+			logger.Warn("Passkey belongs to another user", slog.String("passkey_user_id", passkeyUser.id.String()))
+			s.recordLoginFailureOrLog(ctx, logger, ipScope)
+			return status.Errorf(codes.PermissionDenied, "passkey does not belong to the account being linked")
+		}
+		logger.Info("Account ownership proven by passkey")
+		return nil
+	}
+
+	// 3. 비밀번호
+	if in.Password != "" {
+		userScope := userThrottleScope(user.ID)
+		if err := s.checkLoginThrottle(ctx, userScope); err != nil {
+			logger.Warn("Social link rejected by throttle", slog.String("error", err.Error()))
+			return err
+		}
+		// 비밀번호가 없는 (소셜 로그인) 계정은 항상 실패한다.
+		ok, _, err := s.passwords.Verify(in.Password, user.PasswordHash)
+		if err != nil {
+			logger.Error("Failed to verify password", slog.String("error", err.Error()))
+			return status.Errorf(codes.Internal, "failed to verify password: %v", err)
+		}
+		if !ok {
+			logger.Warn("Invalid password for social link")
+			s.recordLoginFailureOrLog(ctx, logger, userScope, ipScope)
+			return status.Errorf(codes.Unauthenticated, "invalid password")
+		}
+		logger.Info("Account ownership proven by password")
+		return nil
+	}
+
+	logger.Warn("Social link attempted without proof of account ownership")
+	return status.Errorf(codes.Unauthenticated, "password, passkey or an access token for the existing account is required")
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storedUser 가짜 users 테이블의 행
+type storedUser struct {
+	id           uuid.UUID
+	email        string
+	passwordHash string
+	verified     bool
+}
+
+// storedIdentity 가짜 user_identities 테이블의 행
+type storedIdentity struct {
+	id             uuid.UUID
+	userID         uuid.UUID
+	provider       string
+	providerUserID string
+	email          string
+}
+
+// identityStore 소셜 로그인과 계정 연결이 쓰는 users, user_identities 테이블의 가짜 구현.
+// 로그인을 마칠 수 있도록 세션과 토큰 발급 쿼리도 등록한다.
+type identityStore struct {
+	mu         sync.Mutex
+	users      []*storedUser
+	identities []*storedIdentity
+}
+
+func newIdentityStore(db *fakeDB) *identityStore {
+	store := &identityStore{}
+	userRow := func(id string) [][]driver.Value {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for _, u := range store.users {
+			if u.id.String() == id {
+				var verifiedAt driver.Value
+				if u.verified {
+					verifiedAt = time.Now()
+				}
+				return [][]driver.Value{{u.id.String(), u.email, u.passwordHash, verifiedAt}}
+			}
+		}
+		return nil
+	}
+	identityRows := func(match func(*storedIdentity) bool) [][]driver.Value {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		var rows [][]driver.Value
+		for _, i := range store.identities {
+			if match(i) {
+				rows = append(rows, []driver.Value{i.id.String(), i.userID.String(), i.provider, i.providerUserID, i.email, time.Now(), nil})
+			}
+		}
+		return rows
+	}
+	identity := func(id string) *storedIdentity {
+		for _, i := range store.identities {
+			if i.id.String() == id {
+				return i
+			}
+		}
+		return nil
+	}
+
+	db.handle("GetUserByID", func(args []driver.Value) ([][]driver.Value, error) { return userRow(args[0].(string)), nil })
+	db.handle("GetUserByIDForUpdate", func(args []driver.Value) ([][]driver.Value, error) { return userRow(args[0].(string)), nil })
+	db.handle("GetUserByEmail", func(args []driver.Value) ([][]driver.Value, error) {
+		if u := store.userByEmail(args[0].(string)); u != nil {
+			return userRow(u.id.String()), nil
+		}
+		return nil, nil
+	})
+	db.handle("InsertUser", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.users = append(store.users, &storedUser{
+			id:           uuid.MustParse(args[0].(string)),
+			email:        args[1].(string),
+			passwordHash: args[2].(string),
+		})
+		return [][]driver.Value{{args[0]}}, nil
+	})
+	db.handle("GrantUserRole", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	db.handle("MarkEmailVerified", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for _, u := range store.users {
+			if u.id.String() == args[0] && !u.verified {
+				u.verified = true
+				return affected(1), nil
+			}
+		}
+		return affected(0), nil
+	})
+	db.handle("GetUserIdentity", func(args []driver.Value) ([][]driver.Value, error) {
+		return identityRows(func(i *storedIdentity) bool { return i.provider == args[0] && i.providerUserID == args[1] }), nil
+	})
+	db.handle("ListUserIdentitiesByUser", func(args []driver.Value) ([][]driver.Value, error) {
+		return identityRows(func(i *storedIdentity) bool { return i.userID.String() == args[0] }), nil
+	})
+	db.handle("InsertUserIdentity", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.identities = append(store.identities, &storedIdentity{
+			id:             uuid.MustParse(args[0].(string)),
+			userID:         uuid.MustParse(args[1].(string)),
+			provider:       args[2].(string),
+			providerUserID: args[3].(string),
+			email:          args[4].(string),
+		})
+		return affected(1), nil
+	})
+	db.handle("ClaimUserIdentity", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		if i := identity(args[0].(string)); i != nil {
+			i.providerUserID = args[1].(string)
+			return affected(1), nil
+		}
+		return affected(0), nil
+	})
+	db.handle("UpdateUserIdentityLogin", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		if i := identity(args[0].(string)); i != nil {
+			i.email = args[1].(string)
+			return affected(1), nil
+		}
+		return affected(0), nil
+	})
+	db.handle("DeleteUserIdentity", func(args []driver.Value) ([][]driver.Value, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for n, i := range store.identities {
+			if i.id.String() == args[0] && i.userID.String() == args[1] {
+				store.identities = append(store.identities[:n], store.identities[n+1:]...)
+				return affected(1), nil
+			}
+		}
+		return affected(0), nil
+	})
+	db.handle("ListWebAuthnCredentialsByUser", func(args []driver.Value) ([][]driver.Value, error) { return nil, nil })
+	db.handle("GetUserTOTP", func(args []driver.Value) ([][]driver.Value, error) { return nil, nil })
+	db.handle("InsertSession", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	db.handle("ListUserRoles", func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{roleCustomer}}, nil
+	})
+	db.handle("InsertRefreshToken", func(args []driver.Value) ([][]driver.Value, error) { return affected(1), nil })
+	return store
+}
+
+// addUser 사용자를 추가한다. password 가 비어 있으면 비밀번호가 없는 사용자이다.
+func (store *identityStore) addUser(t *testing.T, s *AccountService, email, password string, verified bool) uuid.UUID {
+	t.Helper()
+	u := &storedUser{id: uuid.New(), email: email, verified: verified}
+	if password != "" {
+		hash, err := s.hashPassword(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u.passwordHash = hash
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.users = append(store.users, u)
+	return u.id
+}
+
+// addIdentity userID 에 제공자 계정을 연결한다.
+func (store *identityStore) addIdentity(userID uuid.UUID, provider, providerUserID, email string) uuid.UUID {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	i := &storedIdentity{id: uuid.New(), userID: userID, provider: provider, providerUserID: providerUserID, email: email}
+	store.identities = append(store.identities, i)
+	return i.id
+}
+
+func (store *identityStore) userByEmail(email string) *storedUser {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, u := range store.users {
+		if email != "" && u.email == email {
+			return u
+		}
+	}
+	return nil
+}
+
+// identitiesOf userID 에 연결된 제공자 계정
+func (store *identityStore) identitiesOf(userID uuid.UUID) []storedIdentity {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var identities []storedIdentity
+	for _, i := range store.identities {
+		if i.userID == userID {
+			identities = append(identities, *i)
+		}
+	}
+	return identities
+}
+
+// socialLogin GetSocialLoginURL 로 받은 state 와 state_binding 으로 SocialCallback 을 호출한다.
+func socialLogin(t *testing.T, s *AccountService, provider string) (*pb.SocialCallbackResponse, error) {
+	t.Helper()
+	state, binding := loginState(t, s, provider)
+	return s.SocialCallback(context.Background(), &pb.SocialCallbackRequest{
+		Provider:     provider,
+		Code:         "code",
+		State:        state,
+		StateBinding: binding,
+	})
+}
+
+func TestSocialLoginFindsUserByIdentity(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newIdentityStore(db)
+	userID := store.addUser(t, s, "", "", false)
+	store.addIdentity(userID, oauth.ProviderKakao, "kakao-1", "")
+	withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1"})
+
+	resp, err := socialLogin(t, s, oauth.ProviderKakao)
+	if err != nil {
+		t.Fatalf("SocialCallback: %v", err)
+	}
+	if resp.UserId != userID.String() || resp.NewUser || resp.AccessToken == "" {
+		t.Errorf("response = %+v, want a login as %s", resp, userID)
+	}
+}
+
+// 이메일을 주지 않는 카카오 사용자끼리 빈 이메일로 같은 계정에 묶이지 않는다.
+func TestSocialLoginWithoutEmailCreatesSeparateUsers(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newIdentityStore(db)
+	provider := withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1"})
+
+	first, err := socialLogin(t, s, oauth.ProviderKakao)
+	if err != nil {
+		t.Fatalf("first SocialCallback: %v", err)
+	}
+	provider.profile.Subject = "kakao-2"
+	second, err := socialLogin(t, s, oauth.ProviderKakao)
+	if err != nil {
+		t.Fatalf("second SocialCallback: %v", err)
+	}
+	if !first.NewUser || !second.NewUser || first.UserId == second.UserId {
+		t.Fatalf("first = %+v, second = %+v, want two new users", first, second)
+	}
+	if db.called("GetUserByEmail") != 0 {
+		t.Error("user without an email was looked up by email")
+	}
+	if identities := store.identitiesOf(uuid.MustParse(first.UserId)); len(identities) != 1 || identities[0].providerUserID != "kakao-1" {
+		t.Errorf("first user identities = %+v", identities)
+	}
+}
+
+func TestSocialLoginDoesNotLinkByEmail(t *testing.T) {
+	tests := []struct {
+		name          string
+		emailVerified bool
+		want          codes.Code
+	}{
+		// 제공자가 인증한 이메일이어도 사용자의 확인 없이는 연결하지 않는다.
+		{"verified email", true, codes.OK},
+		// 인증하지 않은 이메일은 연결 확인도 주지 않는다.
+		{"unverified email", false, codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db, _ := newTestService(t)
+			store := newIdentityStore(db)
+			userID := store.addUser(t, s, "user@example.com", "correct horse", true)
+			withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1", Email: "user@example.com", EmailVerified: tt.emailVerified})
+
+			resp, err := socialLogin(t, s, oauth.ProviderKakao)
+			if status.Code(err) != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if tt.want == codes.OK && (!resp.LinkRequired || resp.LinkToken == "" || resp.AccessToken != "") {
+				t.Errorf("response = %+v, want a link confirmation", resp)
+			}
+			if len(store.identitiesOf(userID)) != 0 || db.called("InsertUser") != 0 || db.called("InsertSession") != 0 {
+				t.Error("provider account was linked or signed in without confirmation")
+			}
+		})
+	}
+}
+
+// linkToken 기존 계정 user@example.com 과 같은 이메일의 카카오 계정으로 소셜 로그인해 받은 링크 토큰
+func linkToken(t *testing.T, s *AccountService) string {
+	t.Helper()
+	withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1", Email: "user@example.com", EmailVerified: true})
+	resp, err := socialLogin(t, s, oauth.ProviderKakao)
+	if err != nil {
+		t.Fatalf("SocialCallback: %v", err)
+	}
+	if !resp.LinkRequired {
+		t.Fatalf("response = %+v, want a link confirmation", resp)
+	}
+	return resp.LinkToken
+}
+
+func TestConfirmSocialLinkWithPassword(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newIdentityStore(db)
+	userID := store.addUser(t, s, "user@example.com", "correct horse", false)
+	token := linkToken(t, s)
+
+	resp, err := s.ConfirmSocialLink(context.Background(), &pb.ConfirmSocialLinkRequest{LinkToken: token, Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("ConfirmSocialLink: %v", err)
+	}
+	if resp.UserId != userID.String() || resp.AccessToken == "" {
+		t.Errorf("response = %+v, want a login as %s", resp, userID)
+	}
+	identities := store.identitiesOf(userID)
+	if len(identities) != 1 || identities[0].provider != oauth.ProviderKakao || identities[0].providerUserID != "kakao-1" {
+		t.Errorf("identities = %+v", identities)
+	}
+	// 제공자가 인증한 이메일과 같으므로 계정 이메일도 인증된다.
+	if !resp.EmailVerified || !store.userByEmail("user@example.com").verified {
+		t.Error("account email was not verified")
+	}
+
+	// 연결한 뒤에는 확인 없이 카카오 계정으로 로그인한다.
+	login, err := socialLogin(t, s, oauth.ProviderKakao)
+	if err != nil {
+		t.Fatalf("SocialCallback after link: %v", err)
+	}
+	if login.LinkRequired || login.UserId != userID.String() {
+		t.Errorf("login after link = %+v", login)
+	}
+}
+
+func TestConfirmSocialLinkWithSession(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newIdentityStore(db)
+	userID := store.addUser(t, s, "user@example.com", "", true)
+	otherID := store.addUser(t, s, "other@example.com", "", true)
+
+	// 다른 계정으로 로그인한 토큰으로는 연결할 수 없다.
+	otherCtx, _, _ := signIn(t, s, otherID, roleCustomer)
+	_, err := s.ConfirmSocialLink(otherCtx, &pb.ConfirmSocialLinkRequest{LinkToken: linkToken(t, s)})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("other user's session: got %v, want PermissionDenied", err)
+	}
+
+	ctx, _, _ := signIn(t, s, userID, roleCustomer)
+	if _, err := s.ConfirmSocialLink(ctx, &pb.ConfirmSocialLinkRequest{LinkToken: linkToken(t, s)}); err != nil {
+		t.Fatalf("ConfirmSocialLink: %v", err)
+	}
+	if len(store.identitiesOf(userID)) != 1 || len(store.identitiesOf(otherID)) != 0 {
+		t.Error("identity was not linked to the signed-in owner")
+	}
+}
+
+func TestConfirmSocialLinkRejected(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newIdentityStore(db)
+	userID := store.addUser(t, s, "user@example.com", "correct horse", true)
+	token := linkToken(t, s)
+	ctx := context.Background()
+
+	// 소유 확인 없이는 연결하지 않는다.
+	_, err := s.ConfirmSocialLink(ctx, &pb.ConfirmSocialLinkRequest{LinkToken: token})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("without proof: got %v, want Unauthenticated", err)
+	}
+	// 링크 토큰은 한 번만 쓸 수 있어 맞는 비밀번호로 다시 시도해도 거부된다.
+	_, err = s.ConfirmSocialLink(ctx, &pb.ConfirmSocialLinkRequest{LinkToken: token, Password: "correct horse"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("reused link token: got %v, want InvalidArgument", err)
+	}
+
+	_, err = s.ConfirmSocialLink(ctx, &pb.ConfirmSocialLinkRequest{LinkToken: linkToken(t, s), Password: "wrong horse"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("wrong password: got %v, want Unauthenticated", err)
+	}
+	if len(store.identitiesOf(userID)) != 0 || db.called("InsertSession") != 0 {
+		t.Error("provider account was linked without proof of ownership")
+	}
+}
+
+// 옮겨 온 카카오 가입자는 카카오가 인증한 같은 이메일로 로그인하면 회원번호로 확정된다.
+func TestSocialLoginClaimsLegacyKakaoIdentity(t *testing.T) {
+	s, db, _ := newTestService(t)
+	store := newIdentityStore(db)
+	userID := store.addUser(t, s, "user@example.com", "", true)
+	store.addIdentity(userID, oauth.ProviderKakao, legacyKakaoSubject("user@example.com"), "user@example.com")
+	withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1", Email: "user@example.com", EmailVerified: true})
+
+	resp, err := socialLogin(t, s, oauth.ProviderKakao)
+	if err != nil {
+		t.Fatalf("SocialCallback: %v", err)
+	}
+	if resp.UserId != userID.String() || resp.LinkRequired {
+		t.Errorf("response = %+v, want a login as %s", resp, userID)
+	}
+	if identities := store.identitiesOf(userID); len(identities) != 1 || identities[0].providerUserID != "kakao-1" {
+		t.Errorf("identities = %+v, want the legacy identity claimed", identities)
+	}
+}
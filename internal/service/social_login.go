@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// socialUser 소셜 로그인으로 찾거나 만든 사용자
+type socialUser struct {
+	ID            uuid.UUID
+	IdentityID    uuid.UUID // 로그인한 제공자 계정의 연결
+	HasEmail      bool      // 이메일 없이 가입한 사용자는 이메일 인증 여부로 로그인을 막지 않는다
+	EmailVerified bool
+	Created       bool
+}
+
+// legacyKakaoSubject user_identities 도입 전 카카오 가입자의 자리표시 provider_user_id (000014 마이그레이션).
+func legacyKakaoSubject(email string) string {
+	return "legacy-email:" + strings.ToLower(email)
+}
+
+// socialLink 사용자의 동의를 기다리는 기존 계정과 제공자 계정의 연결.
+// Redis 의 social_link:<sha256(token)> 에 저장한다.
+type socialLink struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Provider       string    `json:"provider"`
+	ProviderUserID string    `json:"provider_user_id"`
+	Email          string    `json:"email"`
+}
+
+// provider 설정된 소셜 로그인 제공자
+func (s *AccountService) provider(name string) (oauth.Provider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported provider %q", name)
+	}
+	return provider, nil
+}
+
+// GetSocialLoginURL 제공자 로그인 URL 을 반환한다.
+// 요청마다 state, PKCE verifier, nonce 를 만들어 저장하고, 콜백은 같은 state 로 한 번만 받을 수 있다.
+// 함께 돌려주는 state_binding 을 가진 클라이언트만 콜백을 마칠 수 있다 (login CSRF 방지).
+func (s *AccountService) GetSocialLoginURL(ctx context.Context, in *pb.GetSocialLoginURLRequest) (*pb.GetSocialLoginURLResponse, error) {
+	logger := s.logger.With("method", "GetSocialLoginURL", "provider", in.Provider)
+
+	provider, err := s.provider(in.Provider)
+	if err != nil {
+		return nil, err
+	}
+	req, binding, err := s.saveOAuthState(ctx, provider.Name(), uuid.Nil)
+	if err != nil {
+		logger.Error("Failed to store oauth state", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store oauth state: %v", err)
+	}
+	return &pb.GetSocialLoginURLResponse{LoginUrl: provider.AuthCodeURL(req), StateBinding: binding}, nil
+}
+
+// socialProfile state 를 확인하고 지운 뒤 (login CSRF, 코드 주입 방지) 인가 코드를 교환해 사용자 정보를 가져온다.
+// userID 는 state 를 만든 사용자이고, 로그인이면 uuid.Nil 이며 binding 이 있어야 한다.
+func (s *AccountService) socialProfile(ctx context.Context, logger *slog.Logger, provider oauth.Provider, userID uuid.UUID, code, state, binding string) (*oauth.Token, *oauth.Profile, error) {
+	if code == "" || state == "" {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "code and state are required")
+	}
+	if userID == uuid.Nil && binding == "" {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "state_binding is required")
+	}
+	req, err := s.takeOAuthState(ctx, provider.Name(), userID, state, binding)
+	if err != nil {
+		logger.Error("Failed to get oauth state", slog.String("error", err.Error()))
+		return nil, nil, status.Errorf(codes.Internal, "failed to get oauth state: %v", err)
+	}
+	if req == nil {
+		logger.Warn("Unknown or expired oauth state")
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid or expired oauth state")
+	}
+
+	token, err := provider.Exchange(ctx, code, *req)
+	if err != nil {
+		logger.Warn("Failed to exchange authorization code", slog.String("error", err.Error()))
+		return nil, nil, status.Errorf(codes.Unauthenticated, "failed to exchange authorization code")
+	}
+	profile, err := provider.Profile(ctx, token, *req)
+	if err != nil {
+		logger.Warn("Failed to get provider profile", slog.String("error", err.Error()))
+		return nil, nil, status.Errorf(codes.Unauthenticated, "failed to get provider profile")
+	}
+	return token, profile, nil
+}
+
+// resolveSocialUser 제공자 계정에 연결된 사용자를 찾는다.
+// 연결이 없으면 새로 가입시키되, 같은 이메일의 계정이 이미 있으면 자동으로 연결하지 않는다.
+// 제공자가 이메일을 인증한 경우에만 연결 확인(socialLink)을 반환하고, 아니면 거부한다.
+// 제공자가 이메일을 주지 않으면 이메일 없이 제공자 계정으로만 찾는 사용자로 가입시킨다.
+// 반환하는 에러는 gRPC status 이다.
+func (s *AccountService) resolveSocialUser(ctx context.Context, qtx *postgresql.Queries, logger *slog.Logger, profile *oauth.Profile) (*socialUser, *socialLink, error) {
+	// 1. 연결된 계정
+	identity, err := qtx.GetUserIdentity(ctx, postgresql.GetUserIdentityParams{
+		Provider:       profile.Provider,
+		ProviderUserID: profile.Subject,
+	})
+	// 회원번호 없이 옮겨 온 카카오 가입자는 카카오가 인증한 같은 이메일일 때만 이 카카오 계정으로 확정한다.
+	if err == sql.ErrNoRows && profile.Provider == oauth.ProviderKakao && profile.Email != "" && profile.EmailVerified {
+		identity, err = qtx.GetUserIdentity(ctx, postgresql.GetUserIdentityParams{
+			Provider:       oauth.ProviderKakao,
+			ProviderUserID: legacyKakaoSubject(profile.Email),
+		})
+		if err == nil {
+			logger.Info("Claiming legacy Kakao identity", slog.String("user_id", identity.UserID.String()))
+			if err := qtx.ClaimUserIdentity(ctx, postgresql.ClaimUserIdentityParams{
+				ID:             identity.ID,
+				ProviderUserID: profile.Subject,
+			}); err != nil {
+				logger.Error("Failed to claim legacy identity", slog.String("error", err.Error()))
+				return nil, nil, status.Errorf(codes.Internal, "failed to claim identity: %v", err)
+			}
+		}
+	}
+	if err == nil {
+		if err := qtx.UpdateUserIdentityLogin(ctx, postgresql.UpdateUserIdentityLoginParams{
+			ID:    identity.ID,
+			Email: profile.Email,
+		}); err != nil {
+			logger.Error("Failed to update identity", slog.String("error", err.Error()))
+			return nil, nil, status.Errorf(codes.Internal, "failed to update identity: %v", err)
+		}
+		user, err := qtx.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			logger.Error("Failed to get user", slog.String("error", err.Error()))
+			return nil, nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+		}
+		return &socialUser{
+			ID:            user.ID,
+			IdentityID:    identity.ID,
+			HasEmail:      user.Email != "",
+			EmailVerified: user.EmailVerifiedAt.Valid,
+		}, nil, nil
+	}
+	if err != sql.ErrNoRows {
+		logger.Error("Failed to get identity", slog.String("error", err.Error()))
+		return nil, nil, status.Errorf(codes.Internal, "failed to get identity: %v", err)
+	}
+
+	// 2. 같은 이메일의 기존 계정. 이메일이 없으면 (카카오 HasEmail=false 등) 제공자 계정으로만 찾는다.
+	if profile.Email == "" {
+		logger.Info("Provider did not return an email", slog.String("subject", profile.Subject))
+	} else if link, err := s.emailLinkCandidate(ctx, qtx, logger, profile); err != nil || link != nil {
+		return nil, link, err
+	}
+
+	// 3. 가입
+	userID, err := qtx.InsertUser(ctx, postgresql.InsertUserParams{
+		ID:           uuid.New(),
+		Email:        profile.Email,
+		PasswordHash: "", // 소셜 로그인 사용자는 비밀번호가 없다
+	})
+	if err != nil {
+		logger.Error("Failed to register user", slog.String("error", err.Error()))
+		return nil, nil, status.Errorf(codes.Internal, "failed to register user: %v", err)
+	}
+	if err := qtx.GrantUserRole(ctx, postgresql.GrantUserRoleParams{
+		UserID: userID,
+		Role:   roleCustomer,
+	}); err != nil {
+		logger.Error("Failed to grant default role", slog.String("error", err.Error()))
+		return nil, nil, status.Errorf(codes.Internal, "failed to grant default role: %v", err)
+	}
+	identityID, err := s.linkIdentity(ctx, qtx, userID, profile.Provider, profile.Subject, profile.Email)
+	if err != nil {
+		logger.Error("Failed to link identity", slog.String("error", err.Error()))
+		return nil, nil, status.Errorf(codes.Internal, "failed to link identity: %v", err)
+	}
+	// 제공자가 확인한 이메일은 인증 메일 없이 인증된 것으로 본다.
+	emailVerified := profile.Email != "" && profile.EmailVerified
+	if emailVerified {
+		if err := qtx.MarkEmailVerified(ctx, userID); err != nil {
+			logger.Error("Failed to mark email verified", slog.String("error", err.Error()))
+			return nil, nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+		}
+	}
+	return &socialUser{
+		ID:            userID,
+		IdentityID:    identityID,
+		HasEmail:      profile.Email != "",
+		EmailVerified: emailVerified,
+		Created:       true,
+	}, nil, nil
+}
+
+// emailLinkCandidate 제공자 이메일과 같은 이메일의 기존 계정이 있으면 연결 확인(socialLink)을 반환한다. 없으면 nil 이다.
+// 제공자가 인증하지 않은 이메일이거나 그 계정에 같은 제공자의 다른 계정이 연결되어 있으면 거부한다.
+// 반환하는 에러는 gRPC status 이다.
+func (s *AccountService) emailLinkCandidate(ctx context.Context, qtx *postgresql.Queries, logger *slog.Logger, profile *oauth.Profile) (*socialLink, error) {
+	existing, err := qtx.GetUserByEmail(ctx, profile.Email)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if !profile.EmailVerified {
+		logger.Warn("Unverified provider email matches existing account", slog.String("user_id", existing.ID.String()))
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"an account with this email already exists, sign in and link %s from account settings", profile.Provider)
+	}
+	identities, err := qtx.ListUserIdentitiesByUser(ctx, existing.ID)
+	if err != nil {
+		logger.Error("Failed to list identities", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to list identities: %v", err)
+	}
+	for _, linked := range identities {
+		if linked.Provider == profile.Provider {
+			logger.Warn("Existing account is linked to another provider account", slog.String("user_id", existing.ID.String()))
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"an account with this email is already linked to another %s account", profile.Provider)
+		}
+	}
+	return &socialLink{
+		UserID:         existing.ID,
+		Provider:       profile.Provider,
+		ProviderUserID: profile.Subject,
+		Email:          profile.Email,
+	}, nil
+}
+
+// linkIdentity 제공자 계정을 사용자에 연결하고 연결 ID 를 반환한다.
+func (s *AccountService) linkIdentity(ctx context.Context, qtx *postgresql.Queries, userID uuid.UUID, provider, providerUserID, email string) (uuid.UUID, error) {
+	identityID := uuid.New()
+	if err := qtx.InsertUserIdentity(ctx, postgresql.InsertUserIdentityParams{
+		ID:             identityID,
+		UserID:         userID,
+		Provider:       provider,
+		ProviderUserID: providerUserID,
+		Email:          email,
+	}); err != nil {
+		return uuid.Nil, err
+	}
+	return identityID, nil
+}
+
+// completeSocialLogin 소셜 로그인으로 확인한 사용자를 로그인시킨다.
+// 2단계 인증이 켜진 계정은 Login 과 같이 토큰 대신 챌린지를 반환한다.
+func (s *AccountService) completeSocialLogin(ctx context.Context, qtx *postgresql.Queries, logger *slog.Logger, user *socialUser, deviceName string) (*pb.SocialCallbackResponse, error) {
+	if user.HasEmail && !user.EmailVerified && s.config.Auth.UnverifiedLogin == unverifiedLoginReject {
+		logger.Warn("Login rejected for unverified email")
+		return nil, status.Errorf(codes.FailedPrecondition, "email not verified")
+	}
+
+	mfaEnabled, err := s.mfaEnabled(ctx, qtx, user.ID)
+	if err != nil {
+		logger.Error("Failed to check MFA", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to check mfa: %v", err)
+	}
+	if mfaEnabled {
+		mfaToken, err := s.createMFAChallenge(ctx, user.ID, deviceName)
+		if err != nil {
+			logger.Error("Failed to create MFA challenge", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to create mfa challenge: %v", err)
+		}
+		logger.Info("MFA required")
+		return &pb.SocialCallbackResponse{
+			UserId:        user.ID.String(),
+			EmailVerified: user.EmailVerified,
+			MfaRequired:   true,
+			MfaToken:      mfaToken,
+			NewUser:       user.Created,
+		}, nil
+	}
+
+	session, err := s.createSession(ctx, qtx, user.ID, deviceName)
+	if err != nil {
+		logger.Error("Failed to create session", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to create session: %v", err)
+	}
+	tokens, err := s.issueTokens(ctx, qtx, session, uuid.Nil)
+	if err != nil {
+		logger.Error("Failed to issue tokens", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
+	}
+
+	logger.Info("Social login successful", slog.String("session_id", session.ID.String()))
+	return &pb.SocialCallbackResponse{
+		UserId:        user.ID.String(),
+		AccessToken:   tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+		EmailVerified: user.EmailVerified,
+		NewUser:       user.Created,
+	}, nil
+}
+
+// SocialCallback 제공자 콜백의 code, state 로 로그인하고 이 서비스의 토큰을 발급한다.
+// 연결된 계정이 없으면 가입시키고, 2단계 인증이 켜진 계정은 Login 과 같이 챌린지를 반환한다.
+func (s *AccountService) SocialCallback(ctx context.Context, in *pb.SocialCallbackRequest) (*pb.SocialCallbackResponse, error) {
+	logger := s.logger.With("method", "SocialCallback", "provider", in.Provider)
+	logger.Info("Starting social login callback")
+
+	provider, err := s.provider(in.Provider)
+	if err != nil {
+		return nil, err
+	}
+	token, profile, err := s.socialProfile(ctx, logger, provider, uuid.Nil, in.Code, in.State, in.StateBinding)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	// 1. 연결된 사용자 찾기 또는 가입
+	user, link, err := s.resolveSocialUser(ctx, qtx, logger, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 같은 이메일의 기존 계정은 사용자가 연결에 동의해야 한다.
+	if link != nil {
+		linkToken, err := s.saveSocialLink(ctx, link)
+		if err != nil {
+			logger.Error("Failed to store social link", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to store social link: %v", err)
+		}
+		logger.Info("Account link confirmation required", slog.String("user_id", link.UserID.String()))
+		return &pb.SocialCallbackResponse{
+			LinkRequired: true,
+			LinkToken:    linkToken,
+			LinkEmail:    link.Email,
+		}, nil
+	}
+	logger = logger.With("user_id", user.ID.String())
+	if user.Created {
+		logger.Info("New user created from social login")
+	}
+
+	// 3. 제공자 토큰 저장 (다른 서비스의 제공자 API 호출용)
+	if err = s.saveProviderToken(ctx, qtx, user.IdentityID, token); err != nil {
+		logger.Error("Failed to store provider token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store provider token: %v", err)
+	}
+
+	// 4. 로그인
+	res, err := s.completeSocialLogin(ctx, qtx, logger, user, in.DeviceName)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
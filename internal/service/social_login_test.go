@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/escape-ship/accountsrv/internal/oauth"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeProvider 인가 코드를 교환하면 profile 을 돌려주는 제공자
+type fakeProvider struct {
+	name    string
+	profile oauth.Profile
+
+	mu        sync.Mutex
+	exchanged int
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) AuthCodeURL(req oauth.AuthRequest) string {
+	return "https://provider.example.com/authorize?state=" + url.QueryEscape(req.State)
+}
+
+func (p *fakeProvider) Exchange(ctx context.Context, code string, req oauth.AuthRequest) (*oauth.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.exchanged++
+	return &oauth.Token{AccessToken: "provider-access-" + code, RefreshToken: "provider-refresh-" + code}, nil
+}
+
+func (p *fakeProvider) Profile(ctx context.Context, token *oauth.Token, req oauth.AuthRequest) (*oauth.Profile, error) {
+	profile := p.profile
+	profile.Provider = p.name
+	return &profile, nil
+}
+
+// withProvider s 에 fakeProvider 를 설정한다.
+func withProvider(s *AccountService, name string, profile oauth.Profile) *fakeProvider {
+	provider := &fakeProvider{name: name, profile: profile}
+	s.providers = map[string]oauth.Provider{name: provider}
+	return provider
+}
+
+// loginState GetSocialLoginURL 이 돌려준 URL 의 state 와 state_binding
+func loginState(t *testing.T, s *AccountService, provider string) (string, string) {
+	t.Helper()
+	resp, err := s.GetSocialLoginURL(context.Background(), &pb.GetSocialLoginURLRequest{Provider: provider})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(resp.LoginUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("state"), resp.StateBinding
+}
+
+func TestSocialCallbackRequiresStateBinding(t *testing.T) {
+	s, _, _ := newTestService(t)
+	provider := withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1"})
+	state, binding := loginState(t, s, oauth.ProviderKakao)
+	if binding == "" {
+		t.Fatal("GetSocialLoginURL returned no state_binding")
+	}
+
+	// 다른 클라이언트에게 넘어간 콜백 URL 은 binding 이 없거나 달라 거부된다.
+	for _, other := range []string{"", "attacker-binding"} {
+		_, err := s.SocialCallback(context.Background(), &pb.SocialCallbackRequest{
+			Provider: oauth.ProviderKakao, Code: "code", State: state, StateBinding: other,
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("binding %q: got %v, want InvalidArgument", other, err)
+		}
+	}
+	if provider.exchanged != 0 {
+		t.Error("authorization code was exchanged without the state binding")
+	}
+}
+
+func TestKakaoCallbackRequiresStateBinding(t *testing.T) {
+	s, _, _ := newTestService(t)
+	provider := withProvider(s, oauth.ProviderKakao, oauth.Profile{Subject: "kakao-1"})
+	resp, err := s.GetKakaoLoginURL(context.Background(), &pb.GetKakaoLoginURLRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StateBinding == "" {
+		t.Fatal("GetKakaoLoginURL returned no state_binding")
+	}
+	u, _ := url.Parse(resp.LoginUrl)
+
+	_, err = s.GetKakaoCallBack(context.Background(), &pb.GetKakaoCallBackRequest{Code: "code", State: u.Query().Get("state")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v, want InvalidArgument", err)
+	}
+	if provider.exchanged != 0 {
+		t.Error("authorization code was exchanged without the state binding")
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// accessClaims 액세스 토큰 클레임
+type accessClaims struct {
+	SessionID     string   `json:"sid"`
+	EmailVerified bool     `json:"email_verified"`
+	Roles         []string `json:"roles,omitempty"`
+	Scope         string   `json:"scope,omitempty"` // 공백으로 구분된 scope 목록 (RFC 9068)
+	jwt.RegisteredClaims
+}
+
+// tokenPair 발급된 액세스 토큰과 리프레시 토큰 묶음
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// issueTokens 세션에 대한 액세스 토큰과 리프레시 토큰을 새로 발급하고 저장한다.
+// familyID 가 uuid.Nil 이면 새로운 리프레시 토큰 패밀리를 시작한다.
+func (s *AccountService) issueTokens(ctx context.Context, qtx *postgresql.Queries, session *postgresql.AccountSession, familyID uuid.UUID) (*tokenPair, error) {
+	userID := session.UserID
+	logger := s.logger.With("method", "issueTokens", "user_id", userID.String(), "session_id", session.ID.String())
+
+	// 1. 액세스 토큰 생성
+	logger.Debug("Loading user claims")
+	user, err := qtx.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	roles, err := qtx.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+	logger.Debug("Generating access token")
+	accessToken, err := s.generateAccessToken(userID, session.ID, user.EmailVerifiedAt.Valid, roles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	// Redis에 세션 저장
+	logger.Debug("Storing session in Redis")
+	if err := s.cacheSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to store session: %w", err)
+	}
+
+	// 2. 리프레시 토큰 생성
+	logger.Debug("Generating refresh token")
+	refreshTokenID := uuid.New()
+	if familyID == uuid.Nil {
+		familyID = refreshTokenID
+	}
+	expiresAt := time.Now().Add(s.config.Auth.RefreshTokenTTL)
+	refreshToken, err := s.generateRefreshToken(userID, refreshTokenID, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	// DB에 저장
+	logger.Debug("Storing refresh token in database", slog.String("refresh_token_id", refreshTokenID.String()))
+	if err := qtx.InsertRefreshToken(ctx, postgresql.InsertRefreshTokenParams{
+		ID:        refreshTokenID,
+		UserID:    userID,
+		FamilyID:  familyID,
+		SessionID: uuid.NullUUID{UUID: session.ID, Valid: true},
+		TokenHash: auth.HashToken(refreshToken),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &tokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// generateAccessToken 액세스 토큰 생성. 이메일 인증 여부와 역할은 email_verified, roles 클레임으로,
+// 역할에서 얻은 scope 는 scope 클레임으로 들어간다.
+func (s *AccountService) generateAccessToken(userID, sessionID uuid.UUID, emailVerified bool, roles []string) (string, error) {
+	logger := s.logger.With("method", "generateAccessToken", "user_id", userID.String())
+	logger.Debug("Generating access token")
+
+	claims := accessClaims{
+		SessionID:        sessionID.String(),
+		EmailVerified:    emailVerified,
+		Roles:            roles,
+		Scope:            scopeForRoles(roles),
+		RegisteredClaims: s.registeredClaims(userID, uuid.NewString(), time.Now().Add(s.config.Auth.AccessTokenTTL)),
+	}
+	signedToken, err := s.keys.Sign(claims)
+	if err != nil {
+		logger.Error("Failed to sign access token", slog.String("error", err.Error()))
+		return "", err
+	}
+
+	logger.Debug("Access token generated successfully")
+	return signedToken, nil
+}
+
+// generateRefreshToken 리프레시 토큰 생성. tokenID 는 jti 로 들어가며
+// 같은 시각에 발급된 토큰끼리도 값이 겹치지 않게 한다.
+// expiresAt 은 DB 에 저장하는 만료 시각과 같은 값을 넘긴다.
+func (s *AccountService) generateRefreshToken(userID, tokenID uuid.UUID, expiresAt time.Time) (string, error) {
+	logger := s.logger.With("method", "generateRefreshToken", "user_id", userID.String())
+	logger.Debug("Generating refresh token")
+
+	claims := s.registeredClaims(userID, tokenID.String(), expiresAt)
+	signedToken, err := s.keys.Sign(claims)
+	if err != nil {
+		logger.Error("Failed to sign refresh token", slog.String("error", err.Error()))
+		return "", err
+	}
+
+	logger.Debug("Refresh token generated successfully")
+	return signedToken, nil
+}
+
+// registeredClaims 발급하는 모든 토큰에 공통으로 들어가는 클레임 (iss, aud, sub, jti, iat, exp)
+func (s *AccountService) registeredClaims(userID uuid.UUID, jti string, expiresAt time.Time) jwt.RegisteredClaims {
+	claims := jwt.RegisteredClaims{
+		Issuer:    s.config.Auth.Issuer,
+		Subject:   userID.String(),
+		ID:        jti,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+	if len(s.config.Auth.Audience) > 0 {
+		claims.Audience = jwt.ClaimStrings(s.config.Auth.Audience)
+	}
+	return claims
+}
+
+// parserOptions 설정된 iss, aud 를 검증하는 옵션
+func (s *AccountService) parserOptions() []jwt.ParserOption {
+	var opts []jwt.ParserOption
+	if s.config.Auth.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.config.Auth.Issuer))
+	}
+	if len(s.config.Auth.Audience) > 0 {
+		opts = append(opts, jwt.WithAudience(s.config.Auth.Audience[0]))
+	}
+	return opts
+}
+
+// parseRefreshToken 리프레시 토큰의 서명과 만료를 검증하고 클레임을 반환한다.
+func (s *AccountService) parseRefreshToken(tokenString string) (*jwt.RegisteredClaims, error) {
+	claims := &jwt.RegisteredClaims{}
+	if err := s.keys.Parse(tokenString, claims, s.parserOptions()...); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// parseAccessToken 액세스 토큰의 서명과 만료를 검증하고 클레임을 반환한다.
+// 세션 ID(sid)가 없는 토큰(리프레시 토큰 등)은 거부한다.
+func (s *AccountService) parseAccessToken(tokenString string) (*accessClaims, error) {
+	claims := &accessClaims{}
+	if err := s.keys.Parse(tokenString, claims, s.parserOptions()...); err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" {
+		return nil, errors.New("access token has no session id")
+	}
+	return claims, nil
+}
+
+// revokeAccessToken 액세스 토큰의 jti 를 남은 유효시간 동안 Redis 거부 목록에 올린다.
+func (s *AccountService) revokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return s.RedisClient.RedisClient.Set(ctx, fmt.Sprintf("revoked_jti:%s", jti), "1", ttl).Err()
+}
+
+// isAccessTokenRevoked 액세스 토큰의 jti 가 거부 목록에 있는지 확인한다.
+func (s *AccountService) isAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.RedisClient.RedisClient.Exists(ctx, fmt.Sprintf("revoked_jti:%s", jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAccessTokenClaims(t *testing.T) {
+	s, _, _ := newTestService(t)
+	s.config.Auth.AccessTokenTTL = 7 * time.Minute
+	s.config.Auth.Audience = []string{"shop", "admin"}
+	userID, sessionID := uuid.New(), uuid.New()
+
+	token, err := s.generateAccessToken(userID, sessionID, true, []string{roleCustomer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.parseAccessToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != "https://accounts.test" || claims.Subject != userID.String() || claims.SessionID != sessionID.String() {
+		t.Errorf("iss/sub/sid = %s/%s/%s", claims.Issuer, claims.Subject, claims.SessionID)
+	}
+	if !slices.Equal(claims.Audience, []string{"shop", "admin"}) {
+		t.Errorf("aud = %v", claims.Audience)
+	}
+	if lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time); lifetime != 7*time.Minute {
+		t.Errorf("access token lifetime = %v, want 7m", lifetime)
+	}
+	if claims.ID == "" {
+		t.Error("access token has no jti")
+	}
+
+	refreshExpires := time.Now().Add(s.config.Auth.RefreshTokenTTL).Truncate(time.Second)
+	refreshToken, err := s.generateRefreshToken(userID, uuid.New(), refreshExpires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshClaims, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !refreshClaims.ExpiresAt.Equal(refreshExpires) {
+		t.Errorf("refresh token exp = %v, want %v", refreshClaims.ExpiresAt.Time, refreshExpires)
+	}
+	// 리프레시 토큰은 sid 가 없으므로 액세스 토큰으로 쓸 수 없다.
+	if _, err := s.parseAccessToken(refreshToken); err == nil {
+		t.Error("refresh token was accepted as an access token")
+	}
+}
+
+func TestParseAccessTokenChecksIssuerAndAudience(t *testing.T) {
+	s, _, _ := newTestService(t)
+	s.config.Auth.Audience = []string{"shop"}
+	userID, sessionID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		issuer   string
+		audience []string
+	}{
+		{"other issuer", "https://other.test", []string{"shop"}},
+		{"other audience", "https://accounts.test", []string{"billing"}},
+		{"no audience", "https://accounts.test", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.config.Auth.Issuer, s.config.Auth.Audience = tt.issuer, tt.audience
+			token, err := s.generateAccessToken(userID, sessionID, true, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.config.Auth.Issuer, s.config.Auth.Audience = "https://accounts.test", []string{"shop"}
+			if _, err := s.parseAccessToken(token); err == nil {
+				t.Error("token was accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	totpPeriod        = 30 * time.Second
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet 헷갈리는 문자(0/O, 1/I)를 뺀 32자. 바이트를 32로 나눈 나머지를 써도 치우치지 않는다.
+	recoveryCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    uint(totpPeriod / time.Second),
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// EnrollTOTP TOTP 비밀키를 새로 만들어 반환한다. ConfirmTOTP 로 확인하기 전에는 로그인에 적용되지 않는다.
+func (s *AccountService) EnrollTOTP(ctx context.Context, in *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	logger := s.logger.With("method", "EnrollTOTP")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Enrolling TOTP")
+
+	if s.sealer == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is not configured")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	user, err := qtx.GetUserByID(ctx, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+
+	current, err := qtx.GetUserTOTPForUpdate(ctx, user.ID)
+	if err == nil && current.ConfirmedAt.Valid {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error("Failed to get TOTP", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get totp: %v", err)
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.config.Auth.TOTPIssuer,
+		AccountName: user.Email,
+		Period:      totpOpts.Period,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		logger.Error("Failed to generate TOTP secret", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to generate totp secret: %v", err)
+	}
+	sealed, err := s.sealer.Seal([]byte(key.Secret()), user.ID[:])
+	if err != nil {
+		logger.Error("Failed to encrypt TOTP secret", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to encrypt totp secret: %v", err)
+	}
+	if err = qtx.UpsertUserTOTP(ctx, postgresql.UpsertUserTOTPParams{
+		UserID: user.ID,
+		Secret: sealed,
+	}); err != nil {
+		logger.Error("Failed to store TOTP secret", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to store totp secret: %v", err)
+	}
+
+	logger.Info("TOTP enrollment started")
+	return &pb.EnrollTOTPResponse{
+		Secret:     key.Secret(),
+		OtpauthUri: key.URL(),
+	}, nil
+}
+
+// ConfirmTOTP 인증 앱의 코드로 등록을 확인하고 2단계 인증을 켠다. 복구 코드는 이때 한 번만 반환한다.
+func (s *AccountService) ConfirmTOTP(ctx context.Context, in *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	logger := s.logger.With("method", "ConfirmTOTP")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Confirming TOTP")
+
+	if in.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	current, err := qtx.GetUserTOTPForUpdate(ctx, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication enrollment not started")
+		}
+		logger.Error("Failed to get TOTP", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get totp: %v", err)
+	}
+	if current.ConfirmedAt.Valid {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+
+	step, ok, err := s.checkTOTPCode(current, in.Code)
+	if err != nil {
+		logger.Error("Failed to check TOTP code", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to check totp code: %v", err)
+	}
+	if !ok {
+		logger.Warn("Invalid TOTP code")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid code")
+	}
+	if err = qtx.ConfirmUserTOTP(ctx, postgresql.ConfirmUserTOTPParams{
+		UserID:       p.UserID,
+		LastUsedStep: step,
+	}); err != nil {
+		logger.Error("Failed to confirm TOTP", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to confirm totp: %v", err)
+	}
+
+	recoveryCodes, err := s.createRecoveryCodes(ctx, qtx, p.UserID)
+	if err != nil {
+		logger.Error("Failed to create recovery codes", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to create recovery codes: %v", err)
+	}
+
+	logger.Info("TOTP enabled")
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTOTP 2단계 인증을 끈다. 켜져 있으면 TOTP 코드나 복구 코드가 필요하다.
+func (s *AccountService) DisableTOTP(ctx context.Context, in *pb.DisableTOTPRequest) (*pb.DisableTOTPResponse, error) {
+	logger := s.logger.With("method", "DisableTOTP")
+
+	p, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("user_id", p.UserID.String())
+	logger.Info("Disabling TOTP")
+
+	db := s.pg.GetDB()
+	querier := postgresql.New(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	qtx := querier.WithTx(tx)
+	defer func() {
+		if err != nil {
+			logger.Warn("Rolling back transaction due to error")
+			tx.Rollback()
+		} else {
+			logger.Debug("Committing transaction")
+			tx.Commit()
+		}
+	}()
+
+	current, err := qtx.GetUserTOTPForUpdate(ctx, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is not enabled")
+		}
+		logger.Error("Failed to get TOTP", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to get totp: %v", err)
+	}
+
+	// 확인 전 등록은 코드 없이 취소할 수 있다.
+	if current.ConfirmedAt.Valid {
+		if in.Code == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "code is required")
+		}
+		totpCode, recoveryCode := in.Code, ""
+		if !isTOTPCode(in.Code) {
+			totpCode, recoveryCode = "", in.Code
+		}
+		ok, err := s.verifySecondFactor(ctx, qtx, current, totpCode, recoveryCode)
+		if err != nil {
+			logger.Error("Failed to verify second factor", slog.String("error", err.Error()))
+			return nil, status.Errorf(codes.Internal, "failed to verify code: %v", err)
+		}
+		if !ok {
+			logger.Warn("Invalid second factor code")
+			return nil, status.Errorf(codes.PermissionDenied, "invalid code")
+		}
+	}
+
+	if err = qtx.DeleteUserTOTP(ctx, p.UserID); err != nil {
+		logger.Error("Failed to delete TOTP", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to disable totp: %v", err)
+	}
+	if err = qtx.DeleteRecoveryCodes(ctx, p.UserID); err != nil {
+		logger.Error("Failed to delete recovery codes", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to delete recovery codes: %v", err)
+	}
+
+	logger.Info("TOTP disabled")
+	return &pb.DisableTOTPResponse{}, nil
+}
+
+// verifySecondFactor TOTP 코드 또는 복구 코드를 확인하고 사용 처리한다.
+// 같은 TOTP 코드(30초 구간)와 복구 코드는 다시 쓸 수 없다.
+func (s *AccountService) verifySecondFactor(ctx context.Context, qtx *postgresql.Queries, current postgresql.AccountUserTotp, code, recoveryCode string) (bool, error) {
+	switch {
+	case code != "":
+		step, ok, err := s.checkTOTPCode(current, code)
+		if err != nil || !ok {
+			return false, err
+		}
+		if err := qtx.UpdateTOTPLastUsedStep(ctx, postgresql.UpdateTOTPLastUsedStepParams{
+			UserID:       current.UserID,
+			LastUsedStep: step,
+		}); err != nil {
+			return false, err
+		}
+		return true, nil
+	case recoveryCode != "":
+		rows, err := qtx.UseRecoveryCode(ctx, postgresql.UseRecoveryCodeParams{
+			UserID:   current.UserID,
+			CodeHash: auth.HashToken(normalizeRecoveryCode(recoveryCode)),
+		})
+		if err != nil {
+			return false, err
+		}
+		return rows == 1, nil
+	default:
+		return false, nil
+	}
+}
+
+// checkTOTPCode 앞뒤 한 구간까지 허용해 코드를 확인하고, 일치한 구간 번호를 반환한다.
+// 마지막으로 사용한 구간 이하의 코드는 재사용으로 보고 거부한다.
+func (s *AccountService) checkTOTPCode(current postgresql.AccountUserTotp, code string) (int64, bool, error) {
+	if s.sealer == nil {
+		return 0, false, errTOTPNotConfigured
+	}
+	secret, err := s.sealer.Open(current.Secret, current.UserID[:])
+	if err != nil {
+		return 0, false, err
+	}
+	now := time.Now()
+	for _, skew := range []time.Duration{0, -totpPeriod, totpPeriod} {
+		t := now.Add(skew)
+		step := t.Unix() / int64(totpPeriod/time.Second)
+		if step <= current.LastUsedStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(string(secret), t, totpOpts)
+		if err != nil {
+			return 0, false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// createRecoveryCodes 기존 복구 코드를 지우고 새 코드를 만들어 해시만 저장한다.
+func (s *AccountService) createRecoveryCodes(ctx context.Context, qtx *postgresql.Queries, userID uuid.UUID) ([]string, error) {
+	if err := qtx.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		if err := qtx.InsertRecoveryCode(ctx, postgresql.InsertRecoveryCodeParams{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: auth.HashToken(normalizeRecoveryCode(code)),
+		}); err != nil {
+			return nil, err
+		}
+		recoveryCodes = append(recoveryCodes, code)
+	}
+	return recoveryCodes, nil
+}
+
+// newRecoveryCode XXXXX-XXXXX 형식 (50비트)
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = recoveryCodeAlphabet[int(b[i])%len(recoveryCodeAlphabet)]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+// normalizeRecoveryCode 대소문자, 하이픈, 공백 차이를 무시한다.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// isTOTPCode 6자리 숫자인지
+func isTOTPCode(code string) bool {
+	if len(code) != totpOpts.Digits.Length() {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/auth"
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+// newTOTPUser 암호화한 TOTP 비밀키를 가진 사용자
+func newTOTPUser(t *testing.T, s *AccountService) postgresql.AccountUserTotp {
+	t.Helper()
+	key := make([]byte, 32)
+	rand.Read(key)
+	sealer, err := auth.NewSealer(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.sealer = sealer
+	current := postgresql.AccountUserTotp{UserID: uuid.New()}
+	current.Secret, err = sealer.Seal([]byte(testTOTPSecret), current.UserID[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return current
+}
+
+// currentTOTPStep 지금의 30초 구간. 구간이 곧 바뀌면 다음 구간까지 기다려 테스트 중에 바뀌지 않게 한다.
+func currentTOTPStep(t *testing.T) (int64, time.Time) {
+	t.Helper()
+	period := int64(totpPeriod / time.Second)
+	now := time.Now()
+	if next := time.Unix((now.Unix()/period+1)*period, 0); time.Until(next) < 2*time.Second {
+		time.Sleep(time.Until(next))
+		now = time.Now()
+	}
+	return now.Unix() / period, now
+}
+
+func totpCodeAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(testTOTPSecret, at, totpOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestCheckTOTPCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   int   // 코드를 만든 구간 - 현재 구간
+		lastUsed int64 // 마지막으로 사용한 구간 - 현재 구간. 0 이하의 값만 의미가 있다
+		code     string
+		ok       bool
+	}{
+		{"current step", 0, -10, "", true},
+		{"previous step", -1, -10, "", true},
+		{"next step", 1, -10, "", true},
+		{"two steps behind", -2, -10, "", false},
+		{"two steps ahead", 2, -10, "", false},
+		{"wrong code", 0, -10, "000000", false},
+		{"current step already used", 0, 0, "", false},
+		{"previous step after current used", -1, 0, "", false},
+		{"next step after current used", 1, 0, "", true},
+		{"current step after previous used", 0, -1, "", true},
+	}
+	s, _, _ := newTestService(t)
+	current := newTOTPUser(t, s)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, now := currentTOTPStep(t)
+			code := tt.code
+			if code == "" {
+				code = totpCodeAt(t, now.Add(time.Duration(tt.offset)*totpPeriod))
+			}
+			current.LastUsedStep = step + tt.lastUsed
+
+			gotStep, ok, err := s.checkTOTPCode(current, code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok {
+				t.Fatalf("checkTOTPCode ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && gotStep != step+int64(tt.offset) {
+				t.Errorf("matched step = %d, want %d", gotStep, step+int64(tt.offset))
+			}
+		})
+	}
+}
+
+func TestVerifySecondFactorRejectsReplay(t *testing.T) {
+	s, db, _ := newTestService(t)
+	current := newTOTPUser(t, s)
+	db.handle("UpdateTOTPLastUsedStep", func(args []driver.Value) ([][]driver.Value, error) {
+		current.LastUsedStep = args[1].(int64)
+		return affected(1), nil
+	})
+	qtx := postgresql.New(db.GetDB())
+
+	step, now := currentTOTPStep(t)
+	code := totpCodeAt(t, now)
+	ok, err := s.verifySecondFactor(context.Background(), qtx, current, code, "")
+	if err != nil || !ok {
+		t.Fatalf("verifySecondFactor = %v, %v, want true", ok, err)
+	}
+	if current.LastUsedStep != step {
+		t.Fatalf("last_used_step = %d, want %d", current.LastUsedStep, step)
+	}
+
+	// 같은 구간의 코드는 다시 쓸 수 없다.
+	ok, err = s.verifySecondFactor(context.Background(), qtx, current, code, "")
+	if err != nil || ok {
+		t.Errorf("replayed code: verifySecondFactor = %v, %v, want false", ok, err)
+	}
+	if db.called("UpdateTOTPLastUsedStep") != 1 {
+		t.Error("last_used_step updated by a rejected code")
+	}
+}
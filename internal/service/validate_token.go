@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ValidateToken 액세스 토큰을 검증한다 (RFC 7662 introspection).
+// 서명/만료/거부 목록/세션 상태 중 하나라도 통과하지 못하면 에러 없이 active=false 를 반환한다.
+func (s *AccountService) ValidateToken(ctx context.Context, in *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	logger := s.logger.With("method", "ValidateToken")
+	logger.Debug("Validate token request received")
+
+	if in.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	p, err := s.verifyAccessToken(ctx, in.Token)
+	if err != nil {
+		if isInactiveToken(err) {
+			logger.Debug("Token is not active", slog.String("reason", err.Error()))
+			return &pb.ValidateTokenResponse{Active: false}, nil
+		}
+		logger.Error("Failed to verify token", slog.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to verify token: %v", err)
+	}
+
+	res := &pb.ValidateTokenResponse{
+		Active:        true,
+		UserId:        p.UserID.String(),
+		SessionId:     p.SessionID.String(),
+		Roles:         p.Roles,
+		Scopes:        p.Scopes,
+		ExpiresAt:     timestamppb.New(p.ExpiresAt),
+		TokenId:       p.TokenID,
+		EmailVerified: p.EmailVerified,
+	}
+	if !p.IssuedAt.IsZero() {
+		res.IssuedAt = timestamppb.New(p.IssuedAt)
+	}
+	logger.Debug("Token is active", slog.String("user_id", res.UserId))
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/escape-ship/accountsrv/internal/infra/sqlc/postgresql"
+	pb "github.com/escape-ship/accountsrv/proto/gen"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestScopeForRoles(t *testing.T) {
+	tests := []struct {
+		roles []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{roleCustomer}, "account"},
+		{[]string{roleAdmin, roleCustomer}, "account admin"},
+		{[]string{roleService}, "provider_token"},
+		{[]string{"unknown"}, ""},
+	}
+	for _, tt := range tests {
+		if got := scopeForRoles(tt.roles); got != tt.want {
+			t.Errorf("scopeForRoles(%v) = %q, want %q", tt.roles, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTokenReturnsScopes(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+	session := &postgresql.AccountSession{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+	if err := s.cacheSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	token, err := s.generateAccessToken(session.UserID, session.ID, true, []string{roleAdmin, roleCustomer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Active {
+		t.Fatal("token is not active")
+	}
+	if want := []string{"account", "admin"}; !slices.Equal(resp.Scopes, want) {
+		t.Errorf("scopes = %v, want %v", resp.Scopes, want)
+	}
+	if want := []string{roleAdmin, roleCustomer}; !slices.Equal(resp.Roles, want) {
+		t.Errorf("roles = %v, want %v", resp.Roles, want)
+	}
+}
+
+func TestValidateTokenRequiresServiceRole(t *testing.T) {
+	s, _, _ := newTestService(t)
+	method := pb.AccountService_ValidateToken_FullMethodName
+
+	// 토큰 원문을 들고 있는 누구나 introspection 을 호출할 수 없도록 service 역할을 요구한다.
+	if _, err := s.authorize(context.Background(), method); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("anonymous caller: got %v, want Unauthenticated", err)
+	}
+	customerCtx, _, _ := signIn(t, s, uuid.New(), roleCustomer)
+	if _, err := s.authorize(customerCtx, method); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("customer caller: got %v, want PermissionDenied", err)
+	}
+	serviceCtx, _, _ := signIn(t, s, uuid.New(), roleService)
+	if _, err := s.authorize(serviceCtx, method); err != nil {
+		t.Errorf("service caller: %v", err)
+	}
+}